@@ -2,11 +2,12 @@ package main
 
 import (
 	"fmt"
+	"maps"
 	"math/rand"
 	"os"
 	"time"
 
-	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apiserver/pkg/server"
 	cloudprovider "k8s.io/cloud-provider"
 	"k8s.io/cloud-provider/app"
 	"k8s.io/cloud-provider/app/config"
@@ -31,13 +32,28 @@ func main() {
 	opts.KubeCloudShared.CloudProvider.Name = xelon.ProviderName
 	opts.Authentication.SkipInClusterLookup = true
 
+	controllerInitFuncConstructors := maps.Clone(app.DefaultInitFuncConstructors)
+	maps.Copy(controllerInitFuncConstructors, xelon.ControllerInitFuncConstructors())
+	// node IPAM is optional, enable it with --controllers=*,xelon-node-ipam
+	app.ControllersDisabledByDefault.Insert(xelon.NodeIPAMControllerName)
+	// garbage collection deletes forwarding rules, enable it with --controllers=*,xelon-lb-gc
+	app.ControllersDisabledByDefault.Insert(xelon.LoadBalancerGCControllerName)
+
+	fss := flag.NamedFlagSets{}
+	xelon.AddFlags(&fss)
+
+	stopCh := server.SetupSignalHandler()
 	command := app.NewCloudControllerManagerCommand(
 		opts,
-		cloudInitializer,
-		app.DefaultInitFuncConstructors,
+		func(c *config.CompletedConfig) cloudprovider.Interface {
+			cloud := cloudInitializer(c)
+			xelon.RunAdmissionWebhook(cloud, stopCh)
+			return cloud
+		},
+		controllerInitFuncConstructors,
 		map[string]string{},
-		flag.NamedFlagSets{},
-		wait.NeverStop,
+		fss,
+		stopCh,
 	)
 
 	logs.InitLogs()
@@ -0,0 +1,178 @@
+package xelon
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// admissionWebhookServicePath is the path of the validating admission webhook
+// for services, it has to be used in ValidatingWebhookConfiguration.
+const admissionWebhookServicePath = "/validate-service"
+
+// admissionWebhook serves validating admission webhook, which rejects services
+// with invalid Xelon annotations at apply time. It is served by all replicas
+// of the cloud controller manager, not only by the leader. Certificate files
+// are reloaded when they change, e.g. when renewed by cert-manager.
+type admissionWebhook struct {
+	certFile string
+	keyFile  string
+	listener net.Listener
+	server   *http.Server
+
+	certMu      sync.Mutex
+	cert        *tls.Certificate
+	certModTime time.Time
+}
+
+// newAdmissionWebhook binds the listener immediately, so invalid addresses
+// are reported at startup.
+func newAdmissionWebhook(config admissionWebhookConfig) (*admissionWebhook, error) {
+	listener, err := net.Listen("tcp", config.BindAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s for admission webhook: %w", config.BindAddress, err)
+	}
+
+	w := &admissionWebhook{
+		certFile: config.CertFile,
+		keyFile:  config.KeyFile,
+		listener: listener,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+admissionWebhookServicePath, w.validateService)
+	w.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		TLSConfig: &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: w.getCertificate,
+		},
+	}
+
+	return w, nil
+}
+
+func (w *admissionWebhook) run(ctx context.Context) {
+	go func() {
+		<-ctx.Done()
+		_ = w.server.Close()
+	}()
+
+	klog.InfoS("Serving admission webhook", "address", w.listener.Addr().String(), "path", admissionWebhookServicePath)
+	if err := w.server.ServeTLS(w.listener, "", ""); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		klog.ErrorS(err, "Failed to serve admission webhook")
+	}
+}
+
+func (w *admissionWebhook) getCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	info, err := os.Stat(w.certFile)
+	if err != nil {
+		return nil, err
+	}
+
+	w.certMu.Lock()
+	defer w.certMu.Unlock()
+
+	if w.cert != nil && info.ModTime().Equal(w.certModTime) {
+		return w.cert, nil
+	}
+	cert, err := tls.LoadX509KeyPair(w.certFile, w.keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load admission webhook certificate: %w", err)
+	}
+	klog.InfoS("Loaded admission webhook certificate", "cert_file", w.certFile)
+	w.cert = &cert
+	w.certModTime = info.ModTime()
+
+	return w.cert, nil
+}
+
+func (w *admissionWebhook) validateService(rw http.ResponseWriter, r *http.Request) {
+	review := &admissionv1.AdmissionReview{}
+	if err := json.NewDecoder(r.Body).Decode(review); err != nil || review.Request == nil {
+		http.Error(rw, "invalid admission review", http.StatusBadRequest)
+		return
+	}
+
+	review.Response = reviewService(review.Request)
+	review.Request = nil
+	rw.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(rw).Encode(review); err != nil {
+		klog.ErrorS(err, "Failed to write admission review response")
+	}
+}
+
+// reviewService allows the request unless the service has invalid Xelon
+// annotations. On updates only annotations added or changed by the request are
+// validated, so services with already invalid annotations can still be updated,
+// e.g. by the cloud controller manager removing its annotations. Services being
+// deleted are always allowed, so finalizers can be removed. Unknown Xelon
+// annotations are returned as warnings.
+func reviewService(request *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	response := &admissionv1.AdmissionResponse{UID: request.UID, Allowed: true}
+	if request.Kind.Kind != "Service" || (request.Operation != admissionv1.Create && request.Operation != admissionv1.Update) {
+		return response
+	}
+
+	service := &v1.Service{}
+	if err := json.Unmarshal(request.Object.Raw, service); err != nil {
+		response.Allowed = false
+		response.Result = &metav1.Status{Code: http.StatusBadRequest, Message: fmt.Sprintf("failed to decode service: %v", err)}
+		return response
+	}
+	if service.Namespace == "" {
+		service.Namespace = request.Namespace
+	}
+	if service.DeletionTimestamp != nil {
+		return response
+	}
+
+	changed := service.Annotations
+	if request.Operation == admissionv1.Update {
+		oldService := &v1.Service{}
+		if err := json.Unmarshal(request.OldObject.Raw, oldService); err != nil {
+			response.Allowed = false
+			response.Result = &metav1.Status{Code: http.StatusBadRequest, Message: fmt.Sprintf("failed to decode old service: %v", err)}
+			return response
+		}
+		changed = changedAnnotations(oldService.Annotations, service.Annotations)
+	}
+	annotations, err := parseServiceAnnotations(changed)
+	if err != nil {
+		klog.V(2).InfoS("Rejected service with invalid annotations", "service", getServiceNameWithNamespace(service), "err", err.Error())
+		response.Allowed = false
+		response.Result = &metav1.Status{
+			Code:    http.StatusUnprocessableEntity,
+			Reason:  metav1.StatusReasonInvalid,
+			Message: fmt.Sprintf("invalid Xelon service annotations: %v", err),
+		}
+		return response
+	}
+	response.Warnings = annotations.warnings()
+
+	return response
+}
+
+// changedAnnotations returns annotations, which are added or changed in the
+// new annotations compared to the old annotations.
+func changedAnnotations(oldAnnotations, newAnnotations map[string]string) map[string]string {
+	changed := make(map[string]string)
+	for key, value := range newAnnotations {
+		if oldValue, ok := oldAnnotations[key]; !ok || oldValue != value {
+			changed[key] = value
+		}
+	}
+	return changed
+}
@@ -0,0 +1,117 @@
+package xelon
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestAdmissionWebhook_validateService(t *testing.T) {
+	type testCase struct {
+		operation        admissionv1.Operation
+		oldAnnotations   map[string]string
+		annotations      map[string]string
+		deleting         bool
+		expectedAllowed  bool
+		expectedMessage  string
+		expectedWarnings []string
+	}
+	tests := map[string]testCase{
+		"valid annotations": {
+			operation:       admissionv1.Create,
+			annotations:     map[string]string{serviceAnnotationLoadBalancerClusterProxyProtocolVersion: "1"},
+			expectedAllowed: true,
+		},
+		"invalid annotations": {
+			operation:       admissionv1.Update,
+			annotations:     map[string]string{serviceAnnotationLoadBalancerClusterProxyProtocolVersion: "abc"},
+			expectedMessage: `invalid Xelon service annotations: annotation service.beta.kubernetes.io/xelon-load-balancer-cluster-proxy-protocol-version: proxy protocol version must be 0, 1 or 2, got "abc"`,
+		},
+		"unknown annotations are warnings": {
+			operation:        admissionv1.Create,
+			annotations:      map[string]string{"service.beta.kubernetes.io/xelon-load-balancer-proxy-protocol": "1"},
+			expectedAllowed:  true,
+			expectedWarnings: []string{"unknown annotation service.beta.kubernetes.io/xelon-load-balancer-proxy-protocol is ignored"},
+		},
+		"update with changed invalid annotation": {
+			operation:       admissionv1.Update,
+			oldAnnotations:  map[string]string{serviceAnnotationLoadBalancerClusterProxyProtocolVersion: "1"},
+			annotations:     map[string]string{serviceAnnotationLoadBalancerClusterProxyProtocolVersion: "abc"},
+			expectedMessage: `invalid Xelon service annotations: annotation service.beta.kubernetes.io/xelon-load-balancer-cluster-proxy-protocol-version: proxy protocol version must be 0, 1 or 2, got "abc"`,
+		},
+		"update with unchanged invalid annotation": {
+			operation: admissionv1.Update,
+			oldAnnotations: map[string]string{
+				serviceAnnotationLoadBalancerClusterProxyProtocolVersion: "abc",
+				serviceAnnotationLoadBalancerClusterID:                   "cluster",
+			},
+			annotations:     map[string]string{serviceAnnotationLoadBalancerClusterProxyProtocolVersion: "abc"},
+			expectedAllowed: true,
+		},
+		"update of service being deleted": {
+			operation:       admissionv1.Update,
+			oldAnnotations:  map[string]string{serviceAnnotationLoadBalancerClusterProxyProtocolVersion: "1"},
+			annotations:     map[string]string{serviceAnnotationLoadBalancerClusterProxyProtocolVersion: "abc"},
+			deleting:        true,
+			expectedAllowed: true,
+		},
+		"delete is always allowed": {
+			operation:       admissionv1.Delete,
+			annotations:     map[string]string{serviceAnnotationLoadBalancerClusterProxyProtocolVersion: "abc"},
+			expectedAllowed: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var deletionTimestamp *metav1.Time
+			if test.deleting {
+				deletionTimestamp = &metav1.Time{Time: time.Now()}
+			}
+			service, err := json.Marshal(&v1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "service", Namespace: "default", Annotations: test.annotations, DeletionTimestamp: deletionTimestamp},
+				Spec:       v1.ServiceSpec{Type: v1.ServiceTypeLoadBalancer},
+			})
+			assert.NoError(t, err)
+			oldService, err := json.Marshal(&v1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "service", Namespace: "default", Annotations: test.oldAnnotations},
+				Spec:       v1.ServiceSpec{Type: v1.ServiceTypeLoadBalancer},
+			})
+			assert.NoError(t, err)
+			body, err := json.Marshal(&admissionv1.AdmissionReview{
+				TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
+				Request: &admissionv1.AdmissionRequest{
+					UID:       "uid",
+					Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "Service"},
+					Operation: test.operation,
+					Object:    runtime.RawExtension{Raw: service},
+					OldObject: runtime.RawExtension{Raw: oldService},
+				},
+			})
+			assert.NoError(t, err)
+			recorder := httptest.NewRecorder()
+
+			(&admissionWebhook{}).validateService(recorder, httptest.NewRequest(http.MethodPost, admissionWebhookServicePath, bytes.NewReader(body)))
+
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			review := &admissionv1.AdmissionReview{}
+			assert.NoError(t, json.NewDecoder(recorder.Body).Decode(review))
+			assert.Equal(t, "admission.k8s.io/v1", review.APIVersion)
+			assert.Equal(t, "uid", string(review.Response.UID))
+			assert.Equal(t, test.expectedAllowed, review.Response.Allowed)
+			assert.Equal(t, test.expectedWarnings, review.Response.Warnings)
+			if test.expectedMessage != "" {
+				assert.Equal(t, test.expectedMessage, review.Response.Result.Message)
+			}
+		})
+	}
+}
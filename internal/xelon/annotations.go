@@ -0,0 +1,256 @@
+package xelon
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Prefixes of Xelon service annotations, unknown annotations with these
+// prefixes are reported as warnings, so typos are detected.
+var serviceAnnotationPrefixes = []string{
+	"service.beta.kubernetes.io/xelon-",
+	"kubernetes.xelon.ch/",
+}
+
+// serviceControllerAnnotations are read-only annotations written by the cloud
+// controller manager, they are removed once the load balancer is released.
+var serviceControllerAnnotations = []string{
+	serviceAnnotationLoadBalancerClusterID,
+	serviceAnnotationLoadBalancerClusterVirtualIPID,
+	serviceAnnotationLoadBalancerClusterForwardingRuleIDs,
+	serviceAnnotationLoadBalancerOwner,
+	serviceAnnotationLoadBalancerMigrationSourceClusterID,
+	serviceAnnotationLoadBalancerMigrationSourceVirtualIPID,
+	serviceAnnotationLoadBalancerMigrationSourceForwardingRuleIDs,
+	serviceAnnotationLoadBalancerMigrationDrainUntil,
+}
+
+// serviceAnnotations holds parsed Xelon annotations of a service. Optional
+// values are nil if the annotation is not set.
+type serviceAnnotations struct {
+	// read-only, managed by the cloud controller manager
+	loadBalancerClusterID string
+	virtualIPID           string
+	forwardingRuleIDs     []string
+	owner                 string
+
+	// read-only, set while the service is migrated to another virtual IP
+	migrationSourceClusterID         string
+	migrationSourceVirtualIPID       string
+	migrationSourceForwardingRuleIDs []string
+	migrationDrainUntil              *time.Time
+
+	proxyProtocolVersion *int
+	targetClusterID      string
+	targetVirtualIPID    string
+	migrationDrainPeriod *time.Duration
+	retainOnDelete       bool
+	claim                string
+
+	// unknown holds sorted keys of unknown annotations with Xelon prefixes
+	unknown []string
+}
+
+// parseServiceAnnotations parses and validates all Xelon annotations of
+// a service. All invalid annotations are reported in the returned error,
+// unknown annotations are ignored and only reported by warnings.
+func parseServiceAnnotations(annotations map[string]string) (*serviceAnnotations, error) {
+	parsed := &serviceAnnotations{}
+	var errs []error
+
+	for key, value := range annotations {
+		if !isXelonServiceAnnotation(key) {
+			continue
+		}
+
+		var err error
+		switch key {
+		case serviceAnnotationLoadBalancerClusterID:
+			parsed.loadBalancerClusterID, err = parseIDAnnotation(value)
+		case serviceAnnotationLoadBalancerClusterVirtualIPID:
+			parsed.virtualIPID, err = parseIDAnnotation(value)
+		case serviceAnnotationLoadBalancerClusterForwardingRuleIDs:
+			parsed.forwardingRuleIDs, err = parseIDListAnnotation(value)
+		case serviceAnnotationLoadBalancerOwner:
+			parsed.owner, err = parseIDAnnotation(value)
+		case serviceAnnotationLoadBalancerMigrationSourceClusterID:
+			parsed.migrationSourceClusterID, err = parseIDAnnotation(value)
+		case serviceAnnotationLoadBalancerMigrationSourceVirtualIPID:
+			parsed.migrationSourceVirtualIPID, err = parseIDAnnotation(value)
+		case serviceAnnotationLoadBalancerMigrationSourceForwardingRuleIDs:
+			parsed.migrationSourceForwardingRuleIDs, err = parseIDListAnnotation(value)
+		case serviceAnnotationLoadBalancerMigrationDrainUntil:
+			parsed.migrationDrainUntil, err = parseTimeAnnotation(value)
+		case serviceAnnotationLoadBalancerClusterProxyProtocolVersion:
+			parsed.proxyProtocolVersion, err = parseProxyProtocolVersion(value)
+		case serviceAnnotationLoadBalancerTargetClusterID:
+			parsed.targetClusterID, err = parseIDAnnotation(value)
+		case serviceAnnotationLoadBalancerTargetVirtualIPID:
+			parsed.targetVirtualIPID, err = parseIDAnnotation(value)
+		case serviceAnnotationLoadBalancerMigrationDrainPeriod:
+			parsed.migrationDrainPeriod, err = parseDurationAnnotation(value)
+		case serviceAnnotationLoadBalancerRetainOnDelete:
+			parsed.retainOnDelete, err = parseBoolAnnotation(value)
+		case serviceAnnotationLoadBalancerClaim:
+			parsed.claim, err = parseServiceNameAnnotation(value)
+		default:
+			parsed.unknown = append(parsed.unknown, key)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("annotation %s: %w", key, err))
+		}
+	}
+	// sort errors and unknown keys, so messages are stable across map iterations
+	slices.SortFunc(errs, func(a, b error) int { return strings.Compare(a.Error(), b.Error()) })
+	slices.Sort(parsed.unknown)
+
+	return parsed, errors.Join(errs...)
+}
+
+// parseControllerAnnotations leniently parses read-only annotations managed by
+// the cloud controller manager and the retain-on-delete policy, which are
+// needed to delete the load balancer of a service. Other annotations are not
+// read at all, so unknown or invalid annotations never block deleting a
+// service. Malformed ids are trimmed and empty ids are skipped, and an invalid
+// retain-on-delete value retains forwarding rules instead of deleting them.
+func parseControllerAnnotations(annotations map[string]string) *serviceAnnotations {
+	parsed := &serviceAnnotations{
+		loadBalancerClusterID:            strings.TrimSpace(annotations[serviceAnnotationLoadBalancerClusterID]),
+		virtualIPID:                      strings.TrimSpace(annotations[serviceAnnotationLoadBalancerClusterVirtualIPID]),
+		forwardingRuleIDs:                parseIDListAnnotationLeniently(annotations[serviceAnnotationLoadBalancerClusterForwardingRuleIDs]),
+		owner:                            strings.TrimSpace(annotations[serviceAnnotationLoadBalancerOwner]),
+		migrationSourceClusterID:         strings.TrimSpace(annotations[serviceAnnotationLoadBalancerMigrationSourceClusterID]),
+		migrationSourceVirtualIPID:       strings.TrimSpace(annotations[serviceAnnotationLoadBalancerMigrationSourceVirtualIPID]),
+		migrationSourceForwardingRuleIDs: parseIDListAnnotationLeniently(annotations[serviceAnnotationLoadBalancerMigrationSourceForwardingRuleIDs]),
+	}
+	if value, ok := annotations[serviceAnnotationLoadBalancerRetainOnDelete]; ok {
+		retainOnDelete, err := parseBoolAnnotation(value)
+		parsed.retainOnDelete = retainOnDelete || err != nil
+	}
+	return parsed
+}
+
+// warnings returns messages about unknown annotations, which are ignored.
+func (a *serviceAnnotations) warnings() []string {
+	var warnings []string
+	for _, key := range a.unknown {
+		warnings = append(warnings, fmt.Sprintf("unknown annotation %s is ignored", key))
+	}
+	return warnings
+}
+
+// hasLoadBalancer returns true if read-only annotations reference a load
+// balancer cluster, virtual IP or forwarding rules.
+func (a *serviceAnnotations) hasLoadBalancer() bool {
+	return a.loadBalancerClusterID != "" || a.virtualIPID != "" || len(a.forwardingRuleIDs) > 0
+}
+
+// isMigrating returns true if the service is migrated to another virtual IP
+// and forwarding rules on the source virtual IP are not deleted yet.
+func (a *serviceAnnotations) isMigrating() bool {
+	return a.migrationSourceClusterID != "" && a.migrationSourceVirtualIPID != ""
+}
+
+func isXelonServiceAnnotation(key string) bool {
+	return slices.ContainsFunc(serviceAnnotationPrefixes, func(prefix string) bool {
+		return strings.HasPrefix(key, prefix)
+	})
+}
+
+func parseIDAnnotation(value string) (string, error) {
+	if strings.TrimSpace(value) != value || strings.Contains(value, ",") {
+		return "", fmt.Errorf("invalid id %q", value)
+	}
+	return value, nil
+}
+
+func parseIDListAnnotation(value string) ([]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+	ids := strings.Split(value, ",")
+	for _, id := range ids {
+		if id == "" || strings.TrimSpace(id) != id {
+			return nil, fmt.Errorf("invalid comma-separated list of ids %q", value)
+		}
+	}
+	return ids, nil
+}
+
+func parseIDListAnnotationLeniently(value string) []string {
+	var ids []string
+	for _, id := range strings.Split(value, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func parseProxyProtocolVersion(value string) (*int, error) {
+	if value == "" {
+		return nil, nil
+	}
+	version, err := strconv.Atoi(value)
+	if err != nil || version < 0 || version > 2 {
+		return nil, fmt.Errorf("proxy protocol version must be 0, 1 or 2, got %q", value)
+	}
+	return &version, nil
+}
+
+func parseDurationAnnotation(value string) (*time.Duration, error) {
+	if value == "" {
+		return nil, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		return nil, fmt.Errorf("invalid non-negative duration %q", value)
+	}
+	return &duration, nil
+}
+
+func parseTimeAnnotation(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RFC 3339 time %q", value)
+	}
+	return &t, nil
+}
+
+func parseBoolAnnotation(value string) (bool, error) {
+	if value == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid boolean %q", value)
+	}
+	return b, nil
+}
+
+func parseServiceNameAnnotation(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	if errs := validation.IsDNS1035Label(value); len(errs) > 0 {
+		return "", fmt.Errorf("invalid service name %q: %s", value, strings.Join(errs, ", "))
+	}
+	return value, nil
+}
+
+// splitAnnotationValue splits comma separated annotation value.
+func splitAnnotationValue(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
@@ -0,0 +1,186 @@
+package xelon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseServiceAnnotations(t *testing.T) {
+	proxyProtocolVersion := 2
+	drainPeriod := 10 * time.Minute
+	drainUntil := time.Date(2024, 1, 1, 0, 10, 0, 0, time.UTC)
+	type testCase struct {
+		annotations map[string]string
+		expected    *serviceAnnotations
+		expectedErr []string
+	}
+	tests := map[string]testCase{
+		"no annotations": {
+			expected: &serviceAnnotations{},
+		},
+		"foreign annotations are ignored": {
+			annotations: map[string]string{"service.beta.kubernetes.io/other": "value", "example.com/xelon": "value"},
+			expected:    &serviceAnnotations{},
+		},
+		"all annotations": {
+			annotations: map[string]string{
+				serviceAnnotationLoadBalancerClusterID:                   "lb-1",
+				serviceAnnotationLoadBalancerClusterVirtualIPID:          "vip-1",
+				serviceAnnotationLoadBalancerClusterForwardingRuleIDs:    "rule-1,rule-2",
+				serviceAnnotationLoadBalancerOwner:                       "uid-1",
+				serviceAnnotationLoadBalancerClusterProxyProtocolVersion: "2",
+			},
+			expected: &serviceAnnotations{
+				loadBalancerClusterID: "lb-1",
+				virtualIPID:           "vip-1",
+				forwardingRuleIDs:     []string{"rule-1", "rule-2"},
+				owner:                 "uid-1",
+				proxyProtocolVersion:  &proxyProtocolVersion,
+			},
+		},
+		"empty values": {
+			annotations: map[string]string{
+				serviceAnnotationLoadBalancerClusterForwardingRuleIDs:    "",
+				serviceAnnotationLoadBalancerClusterProxyProtocolVersion: "",
+			},
+			expected: &serviceAnnotations{},
+		},
+		"invalid values": {
+			annotations: map[string]string{
+				serviceAnnotationLoadBalancerClusterVirtualIPID:          "vip-1,vip-2",
+				serviceAnnotationLoadBalancerClusterForwardingRuleIDs:    "rule-1,,rule-2",
+				serviceAnnotationLoadBalancerClusterProxyProtocolVersion: "abc",
+			},
+			expectedErr: []string{
+				"annotation kubernetes.xelon.ch/load-balancer-cluster-forwarding-rule-ids: invalid comma-separated list of ids",
+				"annotation kubernetes.xelon.ch/load-balancer-cluster-virtual-ip-id: invalid id",
+				"annotation service.beta.kubernetes.io/xelon-load-balancer-cluster-proxy-protocol-version: proxy protocol version must be 0, 1 or 2",
+			},
+		},
+		"proxy protocol version out of range": {
+			annotations: map[string]string{serviceAnnotationLoadBalancerClusterProxyProtocolVersion: "3"},
+			expectedErr: []string{"proxy protocol version must be 0, 1 or 2"},
+		},
+		"migration annotations": {
+			annotations: map[string]string{
+				serviceAnnotationLoadBalancerTargetClusterID:                  "lb-2",
+				serviceAnnotationLoadBalancerTargetVirtualIPID:                "vip-2",
+				serviceAnnotationLoadBalancerMigrationDrainPeriod:             "10m",
+				serviceAnnotationLoadBalancerMigrationSourceClusterID:         "lb-1",
+				serviceAnnotationLoadBalancerMigrationSourceVirtualIPID:       "vip-1",
+				serviceAnnotationLoadBalancerMigrationSourceForwardingRuleIDs: "rule-1",
+				serviceAnnotationLoadBalancerMigrationDrainUntil:              "2024-01-01T00:10:00Z",
+			},
+			expected: &serviceAnnotations{
+				migrationSourceClusterID:         "lb-1",
+				migrationSourceVirtualIPID:       "vip-1",
+				migrationSourceForwardingRuleIDs: []string{"rule-1"},
+				migrationDrainUntil:              &drainUntil,
+				targetClusterID:                  "lb-2",
+				targetVirtualIPID:                "vip-2",
+				migrationDrainPeriod:             &drainPeriod,
+			},
+		},
+		"retain annotations": {
+			annotations: map[string]string{
+				serviceAnnotationLoadBalancerRetainOnDelete: "true",
+				serviceAnnotationLoadBalancerClaim:          "old-service",
+			},
+			expected: &serviceAnnotations{retainOnDelete: true, claim: "old-service"},
+		},
+		"invalid retain annotations": {
+			annotations: map[string]string{
+				serviceAnnotationLoadBalancerRetainOnDelete: "yes",
+				serviceAnnotationLoadBalancerClaim:          "default/old-service",
+			},
+			expectedErr: []string{
+				"annotation service.beta.kubernetes.io/xelon-load-balancer-claim: invalid service name",
+				"annotation service.beta.kubernetes.io/xelon-load-balancer-retain-on-delete: invalid boolean",
+			},
+		},
+		"invalid migration annotations": {
+			annotations: map[string]string{
+				serviceAnnotationLoadBalancerMigrationDrainPeriod: "-1m",
+				serviceAnnotationLoadBalancerMigrationDrainUntil:  "tomorrow",
+			},
+			expectedErr: []string{
+				"annotation kubernetes.xelon.ch/load-balancer-migration-drain-until: invalid RFC 3339 time",
+				"annotation service.beta.kubernetes.io/xelon-load-balancer-migration-drain-period: invalid non-negative duration",
+			},
+		},
+		"unknown annotations are warnings": {
+			annotations: map[string]string{
+				"service.beta.kubernetes.io/xelon-load-balancer-proxy-protocol": "1",
+				"kubernetes.xelon.ch/unknown":                                   "value",
+				serviceAnnotationLoadBalancerRetainOnDelete:                     "true",
+			},
+			expected: &serviceAnnotations{
+				retainOnDelete: true,
+				unknown:        []string{"kubernetes.xelon.ch/unknown", "service.beta.kubernetes.io/xelon-load-balancer-proxy-protocol"},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			actual, err := parseServiceAnnotations(test.annotations)
+
+			if len(test.expectedErr) > 0 {
+				for _, expectedErr := range test.expectedErr {
+					assert.ErrorContains(t, err, expectedErr)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestParseControllerAnnotations(t *testing.T) {
+	type testCase struct {
+		annotations map[string]string
+		expected    *serviceAnnotations
+	}
+	tests := map[string]testCase{
+		"no annotations": {
+			expected: &serviceAnnotations{},
+		},
+		"other annotations are not read": {
+			annotations: map[string]string{
+				serviceAnnotationLoadBalancerClusterID:                   "lb-1",
+				serviceAnnotationLoadBalancerClusterProxyProtocolVersion: "abc",
+				"kubernetes.xelon.ch/unknown":                            "value",
+			},
+			expected: &serviceAnnotations{loadBalancerClusterID: "lb-1"},
+		},
+		"malformed ids are trimmed": {
+			annotations: map[string]string{
+				serviceAnnotationLoadBalancerClusterVirtualIPID:               " vip-1",
+				serviceAnnotationLoadBalancerClusterForwardingRuleIDs:         "rule-1,, rule-2",
+				serviceAnnotationLoadBalancerMigrationSourceForwardingRuleIDs: ",rule-3",
+			},
+			expected: &serviceAnnotations{
+				virtualIPID:                      "vip-1",
+				forwardingRuleIDs:                []string{"rule-1", "rule-2"},
+				migrationSourceForwardingRuleIDs: []string{"rule-3"},
+			},
+		},
+		"invalid retain-on-delete retains": {
+			annotations: map[string]string{serviceAnnotationLoadBalancerRetainOnDelete: "yes"},
+			expected:    &serviceAnnotations{retainOnDelete: true},
+		},
+		"retain-on-delete disabled": {
+			annotations: map[string]string{serviceAnnotationLoadBalancerRetainOnDelete: "false"},
+			expected:    &serviceAnnotations{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, parseControllerAnnotations(test.annotations))
+		})
+	}
+}
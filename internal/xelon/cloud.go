@@ -1,12 +1,15 @@
 package xelon
 
 import (
-	"context"
 	"fmt"
 	"io"
 	"os"
+	"sync/atomic"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
 	cloudprovider "k8s.io/cloud-provider"
 	"k8s.io/klog/v2"
 
@@ -21,76 +24,163 @@ const (
 	xelonCloudIDEnv             string = "XELON_CLOUD_ID"
 	xelonKubernetesClusterIDEnv string = "XELON_KUBERNETES_CLUSTER_ID"
 	xelonTokenEnv               string = "XELON_TOKEN"
+
+	// podNamespaceEnv is set via downward API to the namespace of the cloud
+	// controller manager, which holds config maps owned by it.
+	podNamespaceEnv string = "POD_NAMESPACE"
 )
 
 type clients struct {
-	k8s   kubernetes.Interface
-	xelon *xelon.Client
+	k8s      kubernetes.Interface
+	recorder record.EventRecorder
+
+	// namespace is the namespace of the cloud controller manager.
+	namespace string
+
+	// xelonClient is swapped atomically whenever credentials are reloaded.
+	xelonClient atomic.Pointer[xelon.Client]
 }
 
 type cloud struct {
-	clients       *clients
-	instances     cloudprovider.InstancesV2
-	loadBalancers cloudprovider.LoadBalancer
+	clients            *clients
+	apiHealth          *apiHealthChecker
+	nodePools          *nodePoolSyncer
+	credentialsWatcher *credentialsWatcher
+	tenant             *tenantResolver
+	instances          *instances
+	loadBalancers      cloudprovider.LoadBalancer
+	loadBalancerGC     *loadBalancerGC
+	inventory          *inventory
+	routes             *routes
+	nodeIPAM           *nodeIPAM
+	admissionWebhook   *admissionWebhook
+}
+
+func newClients(xelonClient *xelon.Client) *clients {
+	c := &clients{namespace: metav1.NamespaceSystem}
+	c.xelonClient.Store(xelonClient)
+	return c
+}
+
+// xelon returns the current Xelon API client.
+func (c *clients) xelon() *xelon.Client {
+	return c.xelonClient.Load()
 }
 
 func init() {
 	cloudprovider.RegisterCloudProvider(ProviderName, func(config io.Reader) (cloudprovider.Interface, error) {
-		return newCloud()
+		cfg, err := readCloudConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		commandLineFlags.apply(cfg)
+		return newCloud(cfg)
 	})
 }
 
-func newCloud() (cloudprovider.Interface, error) {
+func newCloud(cfg *cloudConfig) (cloudprovider.Interface, error) {
 	klog.InfoS("Cloud controller manager information", "provider", ProviderName, "version_info", GetVersionInfo())
 
-	token := os.Getenv(xelonTokenEnv)
-	if token == "" {
-		return nil, fmt.Errorf("environment variable %q is required (use k8s secret)", xelonTokenEnv)
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid cloud config: %w", err)
 	}
 
-	cloudID := os.Getenv(xelonCloudIDEnv)
-	if cloudID == "" {
-		return nil, fmt.Errorf("environment variable %q is required", xelonCloudIDEnv)
+	creds, err := cfg.readCredentials()
+	if err != nil {
+		return nil, err
 	}
 
-	clusterID := os.Getenv(xelonKubernetesClusterIDEnv)
-	if clusterID == "" {
-		return nil, fmt.Errorf("environment variable %q is required", xelonKubernetesClusterIDEnv)
+	if creds.clientID == "" {
+		klog.Warningf("Xelon client id is not configured, set environment variable %s or credentials.clientIDFile of the cloud config", xelonClientIDEnv)
 	}
 
-	opts := []xelon.ClientOption{xelon.WithUserAgent(UserAgent())}
-	if apiURL := os.Getenv(xelonBaseURLEnv); apiURL != "" {
-		opts = append(opts, xelon.WithBaseURL(apiURL))
-	}
-	if clientID := os.Getenv(xelonClientIDEnv); clientID != "" {
-		opts = append(opts, xelon.WithClientID(clientID))
-	} else {
-		// Not yet mandatory but will be in 2024
-		fmt.Printf("WARNING: environment variable %q is required (use k8s secret)", xelonClientIDEnv)
+	if cfg.KubernetesClusterID == "" {
+		klog.InfoS("Kubernetes cluster id is not configured, all nodes are resolved via Xelon devices")
 	}
 
-	xelonClient := xelon.NewClient(token, opts...)
-
-	tenant, _, err := xelonClient.Tenants.GetCurrent(context.Background())
-	if err != nil {
-		return nil, err
+	// tenant is resolved in the background (see Initialize), so Xelon API
+	// does not have to be reachable to start the cloud controller manager
+	clients := newClients(newXelonClient(cfg, creds))
+	if namespace := os.Getenv(podNamespaceEnv); namespace != "" {
+		clients.namespace = namespace
+	}
+	tenant := newTenantResolver(clients)
+
+	c := &cloud{
+		clients:   clients,
+		apiHealth: newAPIHealthChecker(clients, tenant, cfg.API.BaseURL, cfg.API.HealthCheckInterval.Duration),
+		nodePools: newNodePoolSyncer(clients, cfg.KubernetesClusterID, cfg.NodePools.SyncInterval.Duration),
+		tenant:    tenant,
+		instances: newInstances(clients, tenant, cfg.CloudID, cfg.KubernetesClusterID, cfg.Instances),
+	}
+	c.inventory = newInventory(clients, c.instances, cfg.KubernetesClusterID, cfg.Inventory)
+	if cfg.Credentials.TokenFile != "" || cfg.Credentials.ClientIDFile != "" {
+		c.credentialsWatcher = newCredentialsWatcher(cfg, clients, tenant, creds)
+	}
+	if cfg.Features.LoadBalancers {
+		c.loadBalancers = newLoadBalancers(clients, tenant, cfg.CloudID, cfg.KubernetesClusterID, cfg.LoadBalancers)
+		c.loadBalancerGC = newLoadBalancerGC(clients, cfg.KubernetesClusterID, cfg.LoadBalancers)
+	}
+	if cfg.NodeIPAM.NetworkID != "" {
+		c.nodeIPAM = newNodeIPAM(clients, tenant, cfg.KubernetesClusterID, cfg.NodeIPAM)
+	}
+	if cfg.Features.Routes {
+		c.routes = newRoutes(clients, tenant, cfg.Routes.NetworkID)
+	}
+	if cfg.AdmissionWebhook.BindAddress != "" {
+		webhook, err := newAdmissionWebhook(cfg.AdmissionWebhook)
+		if err != nil {
+			return nil, err
+		}
+		c.admissionWebhook = webhook
 	}
 
-	clients := &clients{xelon: xelonClient}
+	return c, nil
+}
 
-	return &cloud{
-		clients:       clients,
-		instances:     newInstances(clients, clusterID),
-		loadBalancers: newLoadBalancers(clients, tenant.ID, cloudID, clusterID),
-	}, nil
+func newXelonClient(cfg *cloudConfig, creds credentials) *xelon.Client {
+	opts := []xelon.ClientOption{xelon.WithUserAgent(UserAgent())}
+	if cfg.API.BaseURL != "" {
+		opts = append(opts, xelon.WithBaseURL(cfg.API.BaseURL))
+	}
+	if creds.clientID != "" {
+		opts = append(opts, xelon.WithClientID(creds.clientID))
+	}
+	return xelon.NewClient(creds.token, opts...)
 }
 
-func (c *cloud) Initialize(clientBuilder cloudprovider.ControllerClientBuilder, _ <-chan struct{}) {
+func (c *cloud) Initialize(clientBuilder cloudprovider.ControllerClientBuilder, stop <-chan struct{}) {
 	config := clientBuilder.ConfigOrDie("xelon-cloud-controller-manager")
 	c.clients.k8s = kubernetes.NewForConfigOrDie(config)
+	c.clients.recorder = newEventRecorder(c.clients.k8s, stop)
+
+	ctx := wait.ContextForChannel(stop)
+	go c.tenant.run(ctx)
+	go c.instances.run(ctx)
+	if c.credentialsWatcher != nil {
+		go c.credentialsWatcher.run(ctx)
+	}
+	if loadBalancers, ok := c.loadBalancers.(*loadBalancers); ok {
+		go loadBalancers.drains.run(ctx)
+	}
+}
+
+// RunAdmissionWebhook serves the admission webhook of the Xelon cloud provider,
+// if it is configured, until stop is closed. Admission webhook is served by
+// all replicas, so it is not started in Initialize or as a controller, which
+// only run on the leader.
+func RunAdmissionWebhook(cloudProvider cloudprovider.Interface, stop <-chan struct{}) {
+	xelonCloud, ok := cloudProvider.(*cloud)
+	if !ok || xelonCloud.admissionWebhook == nil {
+		return
+	}
+	go xelonCloud.admissionWebhook.run(wait.ContextForChannel(stop))
 }
 
 func (c *cloud) LoadBalancer() (cloudprovider.LoadBalancer, bool) {
+	if c.loadBalancers == nil {
+		return nil, false
+	}
 	return c.loadBalancers, true
 }
 
@@ -111,7 +201,10 @@ func (c *cloud) Clusters() (cloudprovider.Clusters, bool) {
 }
 
 func (c *cloud) Routes() (cloudprovider.Routes, bool) {
-	return nil, false
+	if c.routes == nil {
+		return nil, false
+	}
+	return c.routes, true
 }
 
 func (c *cloud) ProviderName() string {
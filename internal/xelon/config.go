@@ -0,0 +1,391 @@
+package xelon
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// cloudConfigVersionV1 is the only supported version of the cloud config file format.
+	cloudConfigVersionV1 = "v1"
+
+	defaultAPIHealthCheckInterval    = time.Minute
+	defaultCredentialsReloadInterval = 30 * time.Second
+	defaultInstancesCacheTTL         = 15 * time.Second
+	defaultInstancesMaxStaleness     = 10 * time.Minute
+	defaultNodePoolsSyncInterval     = time.Minute
+	defaultNodeIPAMNodeCIDRMaskSize  = 24
+	defaultNodeIPAMSyncInterval      = 5 * time.Minute
+	defaultInventorySyncInterval     = time.Minute
+
+	defaultLoadBalancersRetryInterval                = 30 * time.Second
+	defaultLoadBalancersProvisioningRetryInterval    = 30 * time.Second
+	defaultLoadBalancersProvisioningMaxRetryInterval = 5 * time.Minute
+	defaultLoadBalancersProvisioningTimeout          = 30 * time.Minute
+	defaultLoadBalancersGCInterval                   = 10 * time.Minute
+)
+
+// cloudConfig represents the cloud config file passed to the cloud controller
+// manager via --cloud-config flag. Environment variables take precedence over
+// values defined in the file.
+//
+//	version: v1
+//	api:
+//	  baseURL: https://hq.xelon.ch/api/service/
+//	  healthCheckInterval: 1m
+//	credentials:
+//	  tokenFile: /etc/xelon/token
+//	  clientIDFile: /etc/xelon/client-id
+//	  reloadInterval: 30s
+//	cloudID: <cloud id>
+//	kubernetesClusterID: <kubernetes cluster id>
+//	instances:
+//	  cacheTTL: 15s
+//	  maxStaleness: 10m
+//	  deviceFallback: true
+//	nodePools:
+//	  syncInterval: 1m
+//	loadBalancers:
+//	  proxyProtocolVersion: 0
+//	  dryRun: false
+//	  retryInterval: 30s
+//	  provisioningRetryInterval: 30s
+//	  provisioningMaxRetryInterval: 5m
+//	  provisioningTimeout: 30m
+//	  gcInterval: 10m
+//	routes:
+//	  networkID: <network id>
+//	nodeIPAM:
+//	  networkID: <network id>
+//	  nodeCIDRMaskSize: 24
+//	  syncInterval: 5m
+//	inventory:
+//	  syncInterval: 1m
+//	features:
+//	  loadBalancers: true
+//	  routes: false
+//	admissionWebhook:
+//	  bindAddress: :9443
+//	  certFile: /etc/xelon/webhook/tls.crt
+//	  keyFile: /etc/xelon/webhook/tls.key
+type cloudConfig struct {
+	Version string `json:"version"`
+
+	API         apiConfig         `json:"api"`
+	Credentials credentialsConfig `json:"credentials"`
+
+	CloudID             string `json:"cloudID"`
+	KubernetesClusterID string `json:"kubernetesClusterID"`
+
+	Instances     instancesConfig     `json:"instances"`
+	NodePools     nodePoolsConfig     `json:"nodePools"`
+	LoadBalancers loadBalancersConfig `json:"loadBalancers"`
+	Routes        routesConfig        `json:"routes"`
+	NodeIPAM      nodeIPAMConfig      `json:"nodeIPAM"`
+	Inventory     inventoryConfig     `json:"inventory"`
+	Features      featuresConfig      `json:"features"`
+
+	AdmissionWebhook admissionWebhookConfig `json:"admissionWebhook"`
+}
+
+type apiConfig struct {
+	// BaseURL overrides default Xelon API base URL.
+	BaseURL string `json:"baseURL"`
+
+	// HealthCheckInterval defines how often credentials and Xelon API reachability are checked.
+	HealthCheckInterval metav1.Duration `json:"healthCheckInterval"`
+}
+
+type credentialsConfig struct {
+	// TokenFile is a path to the file with Xelon API token, it takes
+	// precedence over XELON_TOKEN and is reloaded when it changes.
+	TokenFile string `json:"tokenFile"`
+
+	// ClientIDFile is a path to the file with Xelon client id, it takes
+	// precedence over XELON_CLIENT_ID and is reloaded when it changes.
+	ClientIDFile string `json:"clientIDFile"`
+
+	// ReloadInterval defines how often credential files are checked for changes.
+	ReloadInterval metav1.Duration `json:"reloadInterval"`
+}
+
+type instancesConfig struct {
+	// CacheTTL defines how often cached nodes are refreshed from Xelon API in the background.
+	CacheTTL metav1.Duration `json:"cacheTTL"`
+
+	// MaxStaleness defines how long cached nodes are served if Xelon API fails.
+	MaxStaleness metav1.Duration `json:"maxStaleness"`
+
+	// DeviceFallback resolves nodes, which are not part of the Kubernetes
+	// cluster (e.g. VMs joined manually), via Xelon devices by provider id or
+	// hostname. Without kubernetesClusterID all nodes are resolved this way.
+	DeviceFallback bool `json:"deviceFallback"`
+}
+
+type nodePoolsConfig struct {
+	// SyncInterval defines how often labels and taints of Xelon node pools
+	// are applied to Kubernetes nodes.
+	SyncInterval metav1.Duration `json:"syncInterval"`
+}
+
+type loadBalancersConfig struct {
+	// ProxyProtocolVersion is used for services without proxy protocol annotation.
+	ProxyProtocolVersion int `json:"proxyProtocolVersion"`
+
+	// DryRun only logs and publishes events about planned changes without
+	// calling Xelon API for create, update or delete and without patching services.
+	DryRun bool `json:"dryRun"`
+
+	// RetryInterval defines when services are retried while Xelon API is not reachable.
+	RetryInterval metav1.Duration `json:"retryInterval"`
+
+	// ProvisioningRetryInterval defines when services are retried the first
+	// time while load balancer cluster is being provisioned or in another
+	// transitional state. The interval is doubled with every retry.
+	ProvisioningRetryInterval metav1.Duration `json:"provisioningRetryInterval"`
+
+	// ProvisioningMaxRetryInterval caps the interval between retries while
+	// load balancer cluster is not active.
+	ProvisioningMaxRetryInterval metav1.Duration `json:"provisioningMaxRetryInterval"`
+
+	// ProvisioningTimeout defines how long a service waits for its load
+	// balancer cluster to become active before giving up with a warning event.
+	ProvisioningTimeout metav1.Duration `json:"provisioningTimeout"`
+
+	// GCInterval defines how often forwarding rules, which are not referenced
+	// by any service anymore, are swept by xelon-lb-gc controller.
+	GCInterval metav1.Duration `json:"gcInterval"`
+}
+
+type routesConfig struct {
+	// NetworkID is the id of Xelon private network, on which routes to pod
+	// CIDRs of the nodes are created.
+	NetworkID string `json:"networkID"`
+}
+
+type nodeIPAMConfig struct {
+	// NetworkID is the id of Xelon private network with ranges reserved for
+	// pods of the cluster. It is required to run xelon-node-ipam controller.
+	NetworkID string `json:"networkID"`
+
+	// NodeCIDRMaskSize is the mask size of pod CIDR assigned to each node.
+	NodeCIDRMaskSize int `json:"nodeCIDRMaskSize"`
+
+	// SyncInterval defines how often all nodes are checked in addition to
+	// watching them, so changed reserved ranges of the network are picked up.
+	SyncInterval metav1.Duration `json:"syncInterval"`
+}
+
+type inventoryConfig struct {
+	// SyncInterval defines how often inventory metrics of Xelon resources are updated.
+	SyncInterval metav1.Duration `json:"syncInterval"`
+}
+
+type admissionWebhookConfig struct {
+	// BindAddress enables validating admission webhook for services on the
+	// given address, e.g. ":9443". The webhook is disabled if empty.
+	BindAddress string `json:"bindAddress"`
+
+	// CertFile is a path to the TLS certificate of the webhook.
+	CertFile string `json:"certFile"`
+
+	// KeyFile is a path to the TLS private key of the webhook.
+	KeyFile string `json:"keyFile"`
+}
+
+type featuresConfig struct {
+	// LoadBalancers enables cloudprovider.LoadBalancer implementation.
+	LoadBalancers bool `json:"loadBalancers"`
+
+	// Routes enables cloudprovider.Routes implementation.
+	Routes bool `json:"routes"`
+}
+
+// credentials holds Xelon API credentials resolved from environment variables or files.
+type credentials struct {
+	token    string
+	clientID string
+}
+
+func defaultCloudConfig() *cloudConfig {
+	return &cloudConfig{
+		Version: cloudConfigVersionV1,
+		API: apiConfig{
+			HealthCheckInterval: metav1.Duration{Duration: defaultAPIHealthCheckInterval},
+		},
+		Credentials: credentialsConfig{
+			ReloadInterval: metav1.Duration{Duration: defaultCredentialsReloadInterval},
+		},
+		Instances: instancesConfig{
+			CacheTTL:       metav1.Duration{Duration: defaultInstancesCacheTTL},
+			MaxStaleness:   metav1.Duration{Duration: defaultInstancesMaxStaleness},
+			DeviceFallback: true,
+		},
+		NodePools: nodePoolsConfig{
+			SyncInterval: metav1.Duration{Duration: defaultNodePoolsSyncInterval},
+		},
+		NodeIPAM: nodeIPAMConfig{
+			NodeCIDRMaskSize: defaultNodeIPAMNodeCIDRMaskSize,
+			SyncInterval:     metav1.Duration{Duration: defaultNodeIPAMSyncInterval},
+		},
+		LoadBalancers: loadBalancersConfig{
+			RetryInterval:                metav1.Duration{Duration: defaultLoadBalancersRetryInterval},
+			ProvisioningRetryInterval:    metav1.Duration{Duration: defaultLoadBalancersProvisioningRetryInterval},
+			ProvisioningMaxRetryInterval: metav1.Duration{Duration: defaultLoadBalancersProvisioningMaxRetryInterval},
+			ProvisioningTimeout:          metav1.Duration{Duration: defaultLoadBalancersProvisioningTimeout},
+			GCInterval:                   metav1.Duration{Duration: defaultLoadBalancersGCInterval},
+		},
+		Inventory: inventoryConfig{
+			SyncInterval: metav1.Duration{Duration: defaultInventorySyncInterval},
+		},
+		Features: featuresConfig{
+			LoadBalancers: true,
+		},
+	}
+}
+
+// readCloudConfig parses cloud config from reader (may be nil if --cloud-config
+// flag is not set) and applies environment variables on top of it.
+func readCloudConfig(config io.Reader) (*cloudConfig, error) {
+	cfg := defaultCloudConfig()
+
+	if config != nil {
+		data, err := io.ReadAll(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cloud config: %w", err)
+		}
+		if len(strings.TrimSpace(string(data))) > 0 {
+			cfg.Version = ""
+			if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+				return nil, fmt.Errorf("failed to parse cloud config: %w", err)
+			}
+		}
+	}
+
+	cfg.applyEnvOverrides()
+
+	return cfg, nil
+}
+
+func (c *cloudConfig) applyEnvOverrides() {
+	if baseURL := os.Getenv(xelonBaseURLEnv); baseURL != "" {
+		c.API.BaseURL = baseURL
+	}
+	if cloudID := os.Getenv(xelonCloudIDEnv); cloudID != "" {
+		c.CloudID = cloudID
+	}
+	if clusterID := os.Getenv(xelonKubernetesClusterIDEnv); clusterID != "" {
+		c.KubernetesClusterID = clusterID
+	}
+}
+
+// validate checks cloud config for required and well-formed values.
+func (c *cloudConfig) validate() error {
+	var errs []error
+
+	if c.Version != cloudConfigVersionV1 {
+		errs = append(errs, fmt.Errorf("unsupported cloud config version %q (supported: %q)", c.Version, cloudConfigVersionV1))
+	}
+	if c.API.BaseURL != "" {
+		if u, err := url.Parse(c.API.BaseURL); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("api.baseURL %q is not a valid URL", c.API.BaseURL))
+		}
+	}
+	if c.CloudID == "" {
+		errs = append(errs, fmt.Errorf("cloudID is required (or environment variable %q)", xelonCloudIDEnv))
+	}
+	if c.KubernetesClusterID == "" && c.Features.LoadBalancers {
+		errs = append(errs, fmt.Errorf("kubernetesClusterID is required if features.loadBalancers is enabled (or environment variable %q)", xelonKubernetesClusterIDEnv))
+	}
+	if c.API.HealthCheckInterval.Duration <= 0 {
+		errs = append(errs, fmt.Errorf("api.healthCheckInterval must be positive, got %v", c.API.HealthCheckInterval.Duration))
+	}
+	if c.Credentials.ReloadInterval.Duration <= 0 {
+		errs = append(errs, fmt.Errorf("credentials.reloadInterval must be positive, got %v", c.Credentials.ReloadInterval.Duration))
+	}
+	if c.Instances.CacheTTL.Duration <= 0 {
+		errs = append(errs, fmt.Errorf("instances.cacheTTL must be positive, got %v", c.Instances.CacheTTL.Duration))
+	}
+	if c.Instances.MaxStaleness.Duration < c.Instances.CacheTTL.Duration {
+		errs = append(errs, fmt.Errorf("instances.maxStaleness must not be less than instances.cacheTTL, got %v", c.Instances.MaxStaleness.Duration))
+	}
+	if c.NodePools.SyncInterval.Duration <= 0 {
+		errs = append(errs, fmt.Errorf("nodePools.syncInterval must be positive, got %v", c.NodePools.SyncInterval.Duration))
+	}
+	if c.Features.Routes && c.Routes.NetworkID == "" {
+		errs = append(errs, errors.New("routes.networkID is required if features.routes is enabled"))
+	}
+	if c.NodeIPAM.NetworkID != "" {
+		if c.KubernetesClusterID == "" {
+			errs = append(errs, errors.New("kubernetesClusterID is required if nodeIPAM.networkID is set"))
+		}
+		if c.NodeIPAM.NodeCIDRMaskSize < 8 || c.NodeIPAM.NodeCIDRMaskSize > 30 {
+			errs = append(errs, fmt.Errorf("nodeIPAM.nodeCIDRMaskSize must be between 8 and 30, got %d", c.NodeIPAM.NodeCIDRMaskSize))
+		}
+		if c.NodeIPAM.SyncInterval.Duration <= 0 {
+			errs = append(errs, fmt.Errorf("nodeIPAM.syncInterval must be positive, got %v", c.NodeIPAM.SyncInterval.Duration))
+		}
+	}
+	if c.LoadBalancers.ProxyProtocolVersion < 0 || c.LoadBalancers.ProxyProtocolVersion > 2 {
+		errs = append(errs, fmt.Errorf("loadBalancers.proxyProtocolVersion must be 0, 1 or 2, got %d", c.LoadBalancers.ProxyProtocolVersion))
+	}
+	if c.LoadBalancers.RetryInterval.Duration <= 0 {
+		errs = append(errs, fmt.Errorf("loadBalancers.retryInterval must be positive, got %v", c.LoadBalancers.RetryInterval.Duration))
+	}
+	if c.LoadBalancers.ProvisioningRetryInterval.Duration <= 0 {
+		errs = append(errs, fmt.Errorf("loadBalancers.provisioningRetryInterval must be positive, got %v", c.LoadBalancers.ProvisioningRetryInterval.Duration))
+	}
+	if c.LoadBalancers.ProvisioningMaxRetryInterval.Duration < c.LoadBalancers.ProvisioningRetryInterval.Duration {
+		errs = append(errs, fmt.Errorf("loadBalancers.provisioningMaxRetryInterval must not be less than loadBalancers.provisioningRetryInterval, got %v", c.LoadBalancers.ProvisioningMaxRetryInterval.Duration))
+	}
+	if c.LoadBalancers.ProvisioningTimeout.Duration <= 0 {
+		errs = append(errs, fmt.Errorf("loadBalancers.provisioningTimeout must be positive, got %v", c.LoadBalancers.ProvisioningTimeout.Duration))
+	}
+	if c.LoadBalancers.GCInterval.Duration <= 0 {
+		errs = append(errs, fmt.Errorf("loadBalancers.gcInterval must be positive, got %v", c.LoadBalancers.GCInterval.Duration))
+	}
+	if c.AdmissionWebhook.BindAddress != "" && (c.AdmissionWebhook.CertFile == "" || c.AdmissionWebhook.KeyFile == "") {
+		errs = append(errs, errors.New("admissionWebhook.certFile and admissionWebhook.keyFile are required if admissionWebhook.bindAddress is set"))
+	}
+	if c.Inventory.SyncInterval.Duration <= 0 {
+		errs = append(errs, fmt.Errorf("inventory.syncInterval must be positive, got %v", c.Inventory.SyncInterval.Duration))
+	}
+
+	return errors.Join(errs...)
+}
+
+// readCredentials resolves Xelon API credentials. Explicitly configured
+// credential files take precedence over environment variables, so rotated
+// files are picked up by credentialsWatcher.
+func (c *cloudConfig) readCredentials() (credentials, error) {
+	token := os.Getenv(xelonTokenEnv)
+	if c.Credentials.TokenFile != "" {
+		data, err := os.ReadFile(c.Credentials.TokenFile)
+		if err != nil {
+			return credentials{}, fmt.Errorf("failed to read token file: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	if token == "" {
+		return credentials{}, fmt.Errorf("token is required, use environment variable %q or credentials.tokenFile (use k8s secret)", xelonTokenEnv)
+	}
+
+	clientID := os.Getenv(xelonClientIDEnv)
+	if c.Credentials.ClientIDFile != "" {
+		data, err := os.ReadFile(c.Credentials.ClientIDFile)
+		if err != nil {
+			return credentials{}, fmt.Errorf("failed to read client id file: %w", err)
+		}
+		clientID = strings.TrimSpace(string(data))
+	}
+
+	return credentials{token: token, clientID: clientID}, nil
+}
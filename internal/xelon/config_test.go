@@ -0,0 +1,382 @@
+package xelon
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestReadCloudConfig(t *testing.T) {
+	type testCase struct {
+		input    string
+		env      map[string]string
+		expected *cloudConfig
+	}
+	tests := map[string]testCase{
+		"empty": {
+			input:    "",
+			expected: defaultCloudConfig(),
+		},
+		"full": {
+			input: `
+version: v1
+api:
+  baseURL: https://example.com/api/
+  healthCheckInterval: 5m
+credentials:
+  tokenFile: /etc/xelon/token
+  clientIDFile: /etc/xelon/client-id
+  reloadInterval: 10s
+cloudID: cloud-id
+kubernetesClusterID: cluster-id
+instances:
+  cacheTTL: 1m
+  maxStaleness: 5m
+  deviceFallback: false
+nodePools:
+  syncInterval: 2m
+loadBalancers:
+  proxyProtocolVersion: 2
+  dryRun: true
+  retryInterval: 10s
+  provisioningRetryInterval: 1m
+  provisioningMaxRetryInterval: 10m
+  provisioningTimeout: 1h
+  gcInterval: 5m
+routes:
+  networkID: network-id
+nodeIPAM:
+  networkID: network-id
+  nodeCIDRMaskSize: 26
+  syncInterval: 1m
+inventory:
+  syncInterval: 30s
+admissionWebhook:
+  bindAddress: :9443
+  certFile: /etc/xelon/webhook/tls.crt
+  keyFile: /etc/xelon/webhook/tls.key
+features:
+  loadBalancers: false
+  routes: true
+`,
+			expected: &cloudConfig{
+				Version: "v1",
+				API:     apiConfig{BaseURL: "https://example.com/api/", HealthCheckInterval: metav1.Duration{Duration: 5 * time.Minute}},
+				Credentials: credentialsConfig{
+					TokenFile:      "/etc/xelon/token",
+					ClientIDFile:   "/etc/xelon/client-id",
+					ReloadInterval: metav1.Duration{Duration: 10 * time.Second},
+				},
+				CloudID:             "cloud-id",
+				KubernetesClusterID: "cluster-id",
+				Instances: instancesConfig{
+					CacheTTL:       metav1.Duration{Duration: time.Minute},
+					MaxStaleness:   metav1.Duration{Duration: 5 * time.Minute},
+					DeviceFallback: false,
+				},
+				NodePools: nodePoolsConfig{SyncInterval: metav1.Duration{Duration: 2 * time.Minute}},
+				LoadBalancers: loadBalancersConfig{
+					ProxyProtocolVersion:         2,
+					DryRun:                       true,
+					RetryInterval:                metav1.Duration{Duration: 10 * time.Second},
+					ProvisioningRetryInterval:    metav1.Duration{Duration: time.Minute},
+					ProvisioningMaxRetryInterval: metav1.Duration{Duration: 10 * time.Minute},
+					ProvisioningTimeout:          metav1.Duration{Duration: time.Hour},
+					GCInterval:                   metav1.Duration{Duration: 5 * time.Minute},
+				},
+				Routes: routesConfig{NetworkID: "network-id"},
+				NodeIPAM: nodeIPAMConfig{
+					NetworkID:        "network-id",
+					NodeCIDRMaskSize: 26,
+					SyncInterval:     metav1.Duration{Duration: time.Minute},
+				},
+				Inventory: inventoryConfig{SyncInterval: metav1.Duration{Duration: 30 * time.Second}},
+				AdmissionWebhook: admissionWebhookConfig{
+					BindAddress: ":9443",
+					CertFile:    "/etc/xelon/webhook/tls.crt",
+					KeyFile:     "/etc/xelon/webhook/tls.key",
+				},
+				Features: featuresConfig{LoadBalancers: false, Routes: true},
+			},
+		},
+		"env overrides": {
+			input: `
+version: v1
+cloudID: cloud-id
+kubernetesClusterID: cluster-id
+`,
+			env: map[string]string{
+				"XELON_BASE_URL":              "https://env.example.com/api/",
+				"XELON_CLOUD_ID":              "env-cloud-id",
+				"XELON_KUBERNETES_CLUSTER_ID": "env-cluster-id",
+			},
+			expected: &cloudConfig{
+				Version:             "v1",
+				API:                 apiConfig{BaseURL: "https://env.example.com/api/", HealthCheckInterval: metav1.Duration{Duration: defaultAPIHealthCheckInterval}},
+				CloudID:             "env-cloud-id",
+				KubernetesClusterID: "env-cluster-id",
+				Credentials:         credentialsConfig{ReloadInterval: metav1.Duration{Duration: defaultCredentialsReloadInterval}},
+				Instances: instancesConfig{
+					CacheTTL:       metav1.Duration{Duration: defaultInstancesCacheTTL},
+					MaxStaleness:   metav1.Duration{Duration: defaultInstancesMaxStaleness},
+					DeviceFallback: true,
+				},
+				NodePools: nodePoolsConfig{SyncInterval: metav1.Duration{Duration: defaultNodePoolsSyncInterval}},
+				NodeIPAM: nodeIPAMConfig{
+					NodeCIDRMaskSize: defaultNodeIPAMNodeCIDRMaskSize,
+					SyncInterval:     metav1.Duration{Duration: defaultNodeIPAMSyncInterval},
+				},
+				LoadBalancers: loadBalancersConfig{
+					RetryInterval:                metav1.Duration{Duration: defaultLoadBalancersRetryInterval},
+					ProvisioningRetryInterval:    metav1.Duration{Duration: defaultLoadBalancersProvisioningRetryInterval},
+					ProvisioningMaxRetryInterval: metav1.Duration{Duration: defaultLoadBalancersProvisioningMaxRetryInterval},
+					ProvisioningTimeout:          metav1.Duration{Duration: defaultLoadBalancersProvisioningTimeout},
+					GCInterval:                   metav1.Duration{Duration: defaultLoadBalancersGCInterval},
+				},
+				Inventory: inventoryConfig{SyncInterval: metav1.Duration{Duration: defaultInventorySyncInterval}},
+				Features:  featuresConfig{LoadBalancers: true},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			for k, v := range test.env {
+				t.Setenv(k, v)
+			}
+
+			actual, err := readCloudConfig(strings.NewReader(test.input))
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestReadCloudConfig_unknownField(t *testing.T) {
+	_, err := readCloudConfig(strings.NewReader("version: v1\nunknown: true\n"))
+
+	assert.Error(t, err)
+}
+
+func TestCloudConfig_validate(t *testing.T) {
+	valid := func() *cloudConfig {
+		cfg := defaultCloudConfig()
+		cfg.CloudID = "cloud-id"
+		cfg.KubernetesClusterID = "cluster-id"
+		return cfg
+	}
+	type testCase struct {
+		input       func() *cloudConfig
+		expectedErr string
+	}
+	tests := map[string]testCase{
+		"valid": {
+			input: valid,
+		},
+		"unsupported version": {
+			input: func() *cloudConfig {
+				cfg := valid()
+				cfg.Version = "v2"
+				return cfg
+			},
+			expectedErr: "unsupported cloud config version",
+		},
+		"invalid base url": {
+			input: func() *cloudConfig {
+				cfg := valid()
+				cfg.API.BaseURL = "not-a-url"
+				return cfg
+			},
+			expectedErr: "api.baseURL",
+		},
+		"missing cloud id": {
+			input: func() *cloudConfig {
+				cfg := valid()
+				cfg.CloudID = ""
+				return cfg
+			},
+			expectedErr: "cloudID is required",
+		},
+		"missing kubernetes cluster id": {
+			input: func() *cloudConfig {
+				cfg := valid()
+				cfg.KubernetesClusterID = ""
+				return cfg
+			},
+			expectedErr: "kubernetesClusterID is required",
+		},
+		"missing kubernetes cluster id without load balancers": {
+			input: func() *cloudConfig {
+				cfg := valid()
+				cfg.KubernetesClusterID = ""
+				cfg.Features.LoadBalancers = false
+				return cfg
+			},
+		},
+		"non-positive cache ttl": {
+			input: func() *cloudConfig {
+				cfg := valid()
+				cfg.Instances.CacheTTL = metav1.Duration{Duration: 0}
+				return cfg
+			},
+			expectedErr: "instances.cacheTTL",
+		},
+		"max staleness less than cache ttl": {
+			input: func() *cloudConfig {
+				cfg := valid()
+				cfg.Instances.MaxStaleness = metav1.Duration{Duration: time.Second}
+				return cfg
+			},
+			expectedErr: "instances.maxStaleness",
+		},
+		"non-positive node pools sync interval": {
+			input: func() *cloudConfig {
+				cfg := valid()
+				cfg.NodePools.SyncInterval = metav1.Duration{Duration: -time.Second}
+				return cfg
+			},
+			expectedErr: "nodePools.syncInterval",
+		},
+		"routes without network id": {
+			input: func() *cloudConfig {
+				cfg := valid()
+				cfg.Features.Routes = true
+				return cfg
+			},
+			expectedErr: "routes.networkID is required",
+		},
+		"invalid node ipam mask size": {
+			input: func() *cloudConfig {
+				cfg := valid()
+				cfg.NodeIPAM.NetworkID = "network-id"
+				cfg.NodeIPAM.NodeCIDRMaskSize = 31
+				return cfg
+			},
+			expectedErr: "nodeIPAM.nodeCIDRMaskSize",
+		},
+		"invalid proxy protocol version": {
+			input: func() *cloudConfig {
+				cfg := valid()
+				cfg.LoadBalancers.ProxyProtocolVersion = 3
+				return cfg
+			},
+			expectedErr: "loadBalancers.proxyProtocolVersion",
+		},
+		"non-positive load balancers retry interval": {
+			input: func() *cloudConfig {
+				cfg := valid()
+				cfg.LoadBalancers.RetryInterval = metav1.Duration{Duration: 0}
+				return cfg
+			},
+			expectedErr: "loadBalancers.retryInterval",
+		},
+		"load balancers provisioning max retry interval less than retry interval": {
+			input: func() *cloudConfig {
+				cfg := valid()
+				cfg.LoadBalancers.ProvisioningMaxRetryInterval = metav1.Duration{Duration: time.Second}
+				return cfg
+			},
+			expectedErr: "loadBalancers.provisioningMaxRetryInterval",
+		},
+		"non-positive load balancers provisioning timeout": {
+			input: func() *cloudConfig {
+				cfg := valid()
+				cfg.LoadBalancers.ProvisioningTimeout = metav1.Duration{Duration: 0}
+				return cfg
+			},
+			expectedErr: "loadBalancers.provisioningTimeout",
+		},
+		"non-positive load balancers gc interval": {
+			input: func() *cloudConfig {
+				cfg := valid()
+				cfg.LoadBalancers.GCInterval = metav1.Duration{Duration: 0}
+				return cfg
+			},
+			expectedErr: "loadBalancers.gcInterval",
+		},
+		"admission webhook without certificate": {
+			input: func() *cloudConfig {
+				cfg := valid()
+				cfg.AdmissionWebhook.BindAddress = ":9443"
+				return cfg
+			},
+			expectedErr: "admissionWebhook.certFile",
+		},
+		"non-positive inventory sync interval": {
+			input: func() *cloudConfig {
+				cfg := valid()
+				cfg.Inventory.SyncInterval = metav1.Duration{Duration: -time.Minute}
+				return cfg
+			},
+			expectedErr: "inventory.syncInterval",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := test.input().validate()
+
+			if test.expectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, test.expectedErr)
+			}
+		})
+	}
+}
+
+func TestCloudConfig_readCredentials(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	clientIDFile := filepath.Join(dir, "client-id")
+	assert.NoError(t, os.WriteFile(tokenFile, []byte("file-token\n"), 0o600))
+	assert.NoError(t, os.WriteFile(clientIDFile, []byte("file-client-id\n"), 0o600))
+
+	cfg := defaultCloudConfig()
+	cfg.Credentials.TokenFile = tokenFile
+	cfg.Credentials.ClientIDFile = clientIDFile
+
+	t.Run("files", func(t *testing.T) {
+		t.Setenv("XELON_TOKEN", "")
+		t.Setenv("XELON_CLIENT_ID", "")
+
+		creds, err := cfg.readCredentials()
+
+		assert.NoError(t, err)
+		assert.Equal(t, credentials{token: "file-token", clientID: "file-client-id"}, creds)
+	})
+
+	t.Run("files take precedence over env", func(t *testing.T) {
+		t.Setenv("XELON_TOKEN", "env-token")
+		t.Setenv("XELON_CLIENT_ID", "env-client-id")
+
+		creds, err := cfg.readCredentials()
+
+		assert.NoError(t, err)
+		assert.Equal(t, credentials{token: "file-token", clientID: "file-client-id"}, creds)
+	})
+
+	t.Run("env without files", func(t *testing.T) {
+		t.Setenv("XELON_TOKEN", "env-token")
+		t.Setenv("XELON_CLIENT_ID", "env-client-id")
+
+		creds, err := defaultCloudConfig().readCredentials()
+
+		assert.NoError(t, err)
+		assert.Equal(t, credentials{token: "env-token", clientID: "env-client-id"}, creds)
+	})
+
+	t.Run("missing token", func(t *testing.T) {
+		t.Setenv("XELON_TOKEN", "")
+
+		_, err := defaultCloudConfig().readCredentials()
+
+		assert.Error(t, err)
+	})
+}
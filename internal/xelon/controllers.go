@@ -0,0 +1,166 @@
+package xelon
+
+import (
+	"context"
+	"fmt"
+
+	cloudprovider "k8s.io/cloud-provider"
+	"k8s.io/cloud-provider/app"
+	"k8s.io/cloud-provider/app/config"
+	genericcontrollermanager "k8s.io/controller-manager/app"
+	"k8s.io/controller-manager/controller"
+	controllerhealthz "k8s.io/controller-manager/pkg/healthz"
+)
+
+const (
+	// apiHealthControllerName is exposed as /healthz/xelon-api check.
+	apiHealthControllerName = "xelon-api"
+
+	// nodePoolsControllerName syncs labels and taints of Xelon node pools to nodes.
+	nodePoolsControllerName = "xelon-node-pools"
+
+	// LoadBalancerGCControllerName deletes forwarding rules created by the
+	// cloud controller manager, which are not referenced by any service
+	// anymore. Rules of services deleted before the rules were recorded are
+	// never deleted. It is disabled by default and has to be enabled via --controllers flag.
+	LoadBalancerGCControllerName = "xelon-lb-gc"
+
+	// inventoryControllerName exposes Xelon resources of the cluster as metrics.
+	inventoryControllerName = "xelon-inventory"
+
+	// NodeIPAMControllerName allocates pod CIDRs from Xelon network, it is
+	// disabled by default and has to be enabled via --controllers flag.
+	NodeIPAMControllerName = "xelon-node-ipam"
+)
+
+// ControllerInitFuncConstructors returns Xelon specific controllers, which are
+// registered alongside app.DefaultInitFuncConstructors.
+func ControllerInitFuncConstructors() map[string]app.ControllerInitFuncConstructor {
+	return map[string]app.ControllerInitFuncConstructor{
+		apiHealthControllerName: {
+			InitContext: app.ControllerInitContext{ClientName: apiHealthControllerName},
+			Constructor: startAPIHealthControllerWrapper,
+		},
+		nodePoolsControllerName: {
+			InitContext: app.ControllerInitContext{ClientName: nodePoolsControllerName},
+			Constructor: startNodePoolsControllerWrapper,
+		},
+		LoadBalancerGCControllerName: {
+			InitContext: app.ControllerInitContext{ClientName: LoadBalancerGCControllerName},
+			Constructor: startLoadBalancerGCControllerWrapper,
+		},
+		inventoryControllerName: {
+			InitContext: app.ControllerInitContext{ClientName: inventoryControllerName},
+			Constructor: startInventoryControllerWrapper,
+		},
+		NodeIPAMControllerName: {
+			InitContext: app.ControllerInitContext{ClientName: NodeIPAMControllerName},
+			Constructor: startNodeIPAMControllerWrapper,
+		},
+	}
+}
+
+// apiHealthController periodically checks Xelon API connectivity and reports
+// it via healthz endpoint and metrics.
+type apiHealthController struct {
+	checker controllerhealthz.UnnamedHealthChecker
+}
+
+func (c *apiHealthController) Name() string {
+	return apiHealthControllerName
+}
+
+func (c *apiHealthController) HealthChecker() controllerhealthz.UnnamedHealthChecker {
+	return c.checker
+}
+
+func startAPIHealthControllerWrapper(_ app.ControllerInitContext, _ *config.CompletedConfig, cloudProvider cloudprovider.Interface) app.InitFunc {
+	return func(ctx context.Context, _ genericcontrollermanager.ControllerContext) (controller.Interface, bool, error) {
+		xelonCloud, ok := cloudProvider.(*cloud)
+		if !ok {
+			return nil, false, nil
+		}
+		go xelonCloud.apiHealth.run(ctx)
+		return &apiHealthController{checker: xelonCloud.apiHealth}, true, nil
+	}
+}
+
+// nodePoolsController applies labels and taints of Xelon node pools to nodes.
+type nodePoolsController struct{}
+
+func (c *nodePoolsController) Name() string {
+	return nodePoolsControllerName
+}
+
+func startNodePoolsControllerWrapper(_ app.ControllerInitContext, _ *config.CompletedConfig, cloudProvider cloudprovider.Interface) app.InitFunc {
+	return func(ctx context.Context, _ genericcontrollermanager.ControllerContext) (controller.Interface, bool, error) {
+		xelonCloud, ok := cloudProvider.(*cloud)
+		if !ok {
+			return nil, false, nil
+		}
+		go xelonCloud.nodePools.run(ctx)
+		return &nodePoolsController{}, true, nil
+	}
+}
+
+// loadBalancerGCController deletes orphaned forwarding rules of Xelon load
+// balancer clusters.
+type loadBalancerGCController struct{}
+
+func (c *loadBalancerGCController) Name() string {
+	return LoadBalancerGCControllerName
+}
+
+func startLoadBalancerGCControllerWrapper(_ app.ControllerInitContext, _ *config.CompletedConfig, cloudProvider cloudprovider.Interface) app.InitFunc {
+	return func(ctx context.Context, _ genericcontrollermanager.ControllerContext) (controller.Interface, bool, error) {
+		xelonCloud, ok := cloudProvider.(*cloud)
+		if !ok || xelonCloud.loadBalancerGC == nil {
+			// load balancers are disabled
+			return nil, false, nil
+		}
+		go xelonCloud.loadBalancerGC.run(ctx)
+		return &loadBalancerGCController{}, true, nil
+	}
+}
+
+// inventoryController exposes Xelon resources of the cluster as metrics.
+type inventoryController struct{}
+
+func (c *inventoryController) Name() string {
+	return inventoryControllerName
+}
+
+func startInventoryControllerWrapper(_ app.ControllerInitContext, _ *config.CompletedConfig, cloudProvider cloudprovider.Interface) app.InitFunc {
+	return func(ctx context.Context, _ genericcontrollermanager.ControllerContext) (controller.Interface, bool, error) {
+		xelonCloud, ok := cloudProvider.(*cloud)
+		if !ok {
+			return nil, false, nil
+		}
+		go xelonCloud.inventory.run(ctx)
+		return &inventoryController{}, true, nil
+	}
+}
+
+// nodeIPAMController assigns pod CIDRs from ranges reserved on Xelon network.
+type nodeIPAMController struct{}
+
+func (c *nodeIPAMController) Name() string {
+	return NodeIPAMControllerName
+}
+
+func startNodeIPAMControllerWrapper(_ app.ControllerInitContext, _ *config.CompletedConfig, cloudProvider cloudprovider.Interface) app.InitFunc {
+	return func(ctx context.Context, controllerContext genericcontrollermanager.ControllerContext) (controller.Interface, bool, error) {
+		xelonCloud, ok := cloudProvider.(*cloud)
+		if !ok {
+			return nil, false, nil
+		}
+		if xelonCloud.nodeIPAM == nil {
+			return nil, false, fmt.Errorf("nodeIPAM.networkID has to be configured to run %s controller", NodeIPAMControllerName)
+		}
+		if err := xelonCloud.nodeIPAM.watchNodes(controllerContext.InformerFactory.Core().V1().Nodes()); err != nil {
+			return nil, false, err
+		}
+		go xelonCloud.nodeIPAM.run(ctx)
+		return &nodeIPAMController{}, true, nil
+	}
+}
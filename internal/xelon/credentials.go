@@ -0,0 +1,70 @@
+package xelon
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+)
+
+// credentialsWatcher periodically re-reads credential files (e.g. projected
+// secret volume) and swaps Xelon client once changed credentials are verified.
+// Rotated credentials may belong to another tenant, so the tenant is reset to
+// the one of the new credentials.
+type credentialsWatcher struct {
+	config  *cloudConfig
+	clients *clients
+	tenant  *tenantResolver
+
+	current  credentials
+	rejected credentials
+	interval time.Duration
+}
+
+func newCredentialsWatcher(config *cloudConfig, clients *clients, tenant *tenantResolver, current credentials) *credentialsWatcher {
+	return &credentialsWatcher{
+		config:   config,
+		clients:  clients,
+		tenant:   tenant,
+		current:  current,
+		interval: config.Credentials.ReloadInterval.Duration,
+	}
+}
+
+func (w *credentialsWatcher) run(ctx context.Context) {
+	klog.InfoS("Watching Xelon credential files for changes",
+		"token_file", w.config.Credentials.TokenFile,
+		"client_id_file", w.config.Credentials.ClientIDFile,
+		"interval", w.interval,
+	)
+	wait.UntilWithContext(ctx, w.reload, w.interval)
+}
+
+// reload swaps Xelon client if credentials have changed. New credentials are
+// verified against Xelon API first, invalid ones are skipped until they change again.
+func (w *credentialsWatcher) reload(ctx context.Context) {
+	creds, err := w.config.readCredentials()
+	if err != nil {
+		klog.ErrorS(err, "Failed to read Xelon credentials, keep using current ones")
+		return
+	}
+	if creds == w.current || creds == w.rejected {
+		return
+	}
+
+	klog.InfoS("Xelon credentials have changed, verifying them")
+	xelonClient := newXelonClient(w.config, creds)
+	tenant, _, err := xelonClient.Tenants.GetCurrent(ctx)
+	if err != nil {
+		klog.ErrorS(err, "Failed to verify new Xelon credentials, keep using current ones")
+		w.rejected = creds
+		return
+	}
+
+	w.clients.xelonClient.Store(xelonClient)
+	w.tenant.reset(tenant.ID)
+	w.current = creds
+	w.rejected = credentials{}
+	klog.InfoS("Xelon credentials reloaded")
+}
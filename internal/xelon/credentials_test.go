@@ -0,0 +1,71 @@
+package xelon
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Xelon-AG/xelon-sdk-go/xelon"
+)
+
+func TestCredentialsWatcher_reload(t *testing.T) {
+	t.Setenv("XELON_TOKEN", "")
+	t.Setenv("XELON_CLIENT_ID", "")
+
+	var authorized atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if !authorized.Load() {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(xelon.Tenant{ID: "new-tenant-id"}))
+	}))
+	defer server.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	assert.NoError(t, os.WriteFile(tokenFile, []byte("old-token"), 0o600))
+
+	cfg := defaultCloudConfig()
+	cfg.API.BaseURL = server.URL + "/"
+	cfg.Credentials.TokenFile = tokenFile
+
+	initialClient := newXelonClient(cfg, credentials{token: "old-token"})
+	c := newClients(initialClient)
+	tenant := &tenantResolver{clients: c, id: "old-tenant-id", resolved: true}
+	w := newCredentialsWatcher(cfg, c, tenant, credentials{token: "old-token"})
+
+	t.Run("unchanged credentials", func(t *testing.T) {
+		w.reload(context.Background())
+
+		assert.Same(t, initialClient, c.xelon())
+	})
+
+	t.Run("invalid credentials", func(t *testing.T) {
+		assert.NoError(t, os.WriteFile(tokenFile, []byte("invalid-token"), 0o600))
+
+		w.reload(context.Background())
+
+		assert.Same(t, initialClient, c.xelon())
+		assert.Equal(t, credentials{token: "invalid-token"}, w.rejected)
+	})
+
+	t.Run("valid credentials", func(t *testing.T) {
+		assert.NoError(t, os.WriteFile(tokenFile, []byte("valid-token"), 0o600))
+		authorized.Store(true)
+
+		w.reload(context.Background())
+
+		assert.NotSame(t, initialClient, c.xelon())
+		assert.Equal(t, credentials{token: "valid-token"}, w.current)
+		id, err := tenant.tenantID(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, "new-tenant-id", id)
+	})
+}
@@ -0,0 +1,81 @@
+package xelon
+
+import (
+	"net"
+	"regexp"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+var invalidLabelValueChars = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// buildNodeAddresses maps network interfaces of the device to node addresses:
+// private IPs are reported as InternalIP, public ones as ExternalIP.
+func buildNodeAddresses(device *xelonDevice, hostname string) []v1.NodeAddress {
+	var internalAddresses, externalAddresses []v1.NodeAddress
+	seen := make(map[string]bool)
+	for _, network := range device.Networks {
+		ip := net.ParseIP(network.IPAddress)
+		if ip == nil || ip.IsUnspecified() || ip.IsLoopback() || ip.IsLinkLocalUnicast() {
+			continue
+		}
+		if seen[ip.String()] {
+			continue
+		}
+		seen[ip.String()] = true
+
+		if ip.IsPrivate() {
+			internalAddresses = append(internalAddresses, v1.NodeAddress{Type: v1.NodeInternalIP, Address: ip.String()})
+		} else {
+			externalAddresses = append(externalAddresses, v1.NodeAddress{Type: v1.NodeExternalIP, Address: ip.String()})
+		}
+	}
+
+	addresses := append(internalAddresses, externalAddresses...)
+	if device.HostName != "" {
+		hostname = device.HostName
+	}
+	if hostname != "" {
+		addresses = append(addresses, v1.NodeAddress{Type: v1.NodeHostName, Address: hostname})
+	}
+
+	return addresses
+}
+
+// getTopology returns region and zone of the device. Region is derived from
+// the cloud (data centre) and zone from the hypervisor cluster. If Xelon API
+// does not provide this information, defaultRegion is used for both.
+func getTopology(device *xelonDevice, defaultRegion string) (string, string) {
+	region := defaultRegion
+	if device.Cloud != nil {
+		region = firstNonEmpty(device.Cloud.Name, device.Cloud.ID, region)
+	}
+
+	zone := region
+	if device.HypervisorCluster != nil {
+		zone = firstNonEmpty(device.HypervisorCluster.Name, device.HypervisorCluster.ID, zone)
+	}
+
+	return sanitizeLabelValue(region), sanitizeLabelValue(zone)
+}
+
+// sanitizeLabelValue converts value to a valid Kubernetes label value.
+func sanitizeLabelValue(value string) string {
+	value = strings.ToLower(value)
+	value = invalidLabelValueChars.ReplaceAllString(value, "-")
+	if len(value) > validation.LabelValueMaxLength {
+		value = value[:validation.LabelValueMaxLength]
+	}
+	return strings.Trim(value, "-_.")
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,121 @@
+package xelon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestBuildNodeAddresses(t *testing.T) {
+	type testCase struct {
+		device   *xelonDevice
+		hostname string
+		expected []v1.NodeAddress
+	}
+	tests := map[string]testCase{
+		"no networks": {
+			device:   &xelonDevice{},
+			hostname: "worker-1",
+			expected: []v1.NodeAddress{
+				{Type: v1.NodeHostName, Address: "worker-1"},
+			},
+		},
+		"private and public networks": {
+			device: &xelonDevice{
+				HostName: "worker-1.example.com",
+				Networks: []xelonDeviceNetwork{
+					{Name: "wan", IPAddress: "185.1.2.3"},
+					{Name: "lan", IPAddress: "10.0.0.10"},
+				},
+			},
+			hostname: "worker-1",
+			expected: []v1.NodeAddress{
+				{Type: v1.NodeInternalIP, Address: "10.0.0.10"},
+				{Type: v1.NodeExternalIP, Address: "185.1.2.3"},
+				{Type: v1.NodeHostName, Address: "worker-1.example.com"},
+			},
+		},
+		"multiple private networks": {
+			device: &xelonDevice{
+				Networks: []xelonDeviceNetwork{
+					{Name: "lan", IPAddress: "10.0.0.10"},
+					{Name: "storage", IPAddress: "192.168.10.10"},
+					{Name: "duplicate", IPAddress: "10.0.0.10"},
+				},
+			},
+			hostname: "worker-1",
+			expected: []v1.NodeAddress{
+				{Type: v1.NodeInternalIP, Address: "10.0.0.10"},
+				{Type: v1.NodeInternalIP, Address: "192.168.10.10"},
+				{Type: v1.NodeHostName, Address: "worker-1"},
+			},
+		},
+		"invalid addresses": {
+			device: &xelonDevice{
+				Networks: []xelonDeviceNetwork{
+					{Name: "empty", IPAddress: ""},
+					{Name: "invalid", IPAddress: "invalid"},
+					{Name: "link-local", IPAddress: "169.254.1.1"},
+				},
+			},
+			expected: nil,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			actual := buildNodeAddresses(test.device, test.hostname)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestGetTopology(t *testing.T) {
+	type testCase struct {
+		device         *xelonDevice
+		defaultRegion  string
+		expectedRegion string
+		expectedZone   string
+	}
+	tests := map[string]testCase{
+		"no topology information": {
+			device:         &xelonDevice{},
+			defaultRegion:  "cloud-id",
+			expectedRegion: "cloud-id",
+			expectedZone:   "cloud-id",
+		},
+		"cloud and hypervisor cluster": {
+			device: &xelonDevice{
+				Cloud:             &xelonDeviceCloud{ID: "1", Name: "Zurich 1"},
+				HypervisorCluster: &xelonDeviceHypervisorCluster{ID: "7", Name: "HV-Cluster_A"},
+			},
+			defaultRegion:  "cloud-id",
+			expectedRegion: "zurich-1",
+			expectedZone:   "hv-cluster_a",
+		},
+		"ids without names": {
+			device: &xelonDevice{
+				Cloud:             &xelonDeviceCloud{ID: "1"},
+				HypervisorCluster: &xelonDeviceHypervisorCluster{ID: "7"},
+			},
+			expectedRegion: "1",
+			expectedZone:   "7",
+		},
+		"cloud without hypervisor cluster": {
+			device: &xelonDevice{
+				Cloud: &xelonDeviceCloud{Name: "(Bern)"},
+			},
+			expectedRegion: "bern",
+			expectedZone:   "bern",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			region, zone := getTopology(test.device, test.defaultRegion)
+			assert.Equal(t, test.expectedRegion, region)
+			assert.Equal(t, test.expectedZone, zone)
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package xelon
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	eventComponent = "xelon-cloud-controller-manager"
+
+	eventReasonForeignAnnotations              = "XelonForeignAnnotations"
+	eventReasonInvalidAnnotations              = "XelonInvalidAnnotations"
+	eventReasonLoadBalancerClaimFailed         = "XelonLoadBalancerClaimFailed"
+	eventReasonLoadBalancerClaimed             = "XelonLoadBalancerClaimed"
+	eventReasonLoadBalancerDryRun              = "XelonLoadBalancerDryRun"
+	eventReasonLoadBalancerMigrated            = "XelonLoadBalancerMigrated"
+	eventReasonLoadBalancerMigrating           = "XelonLoadBalancerMigrating"
+	eventReasonLoadBalancerMigrationRefused    = "XelonLoadBalancerMigrationRefused"
+	eventReasonLoadBalancerReleased            = "XelonLoadBalancerReleased"
+	eventReasonLoadBalancerRetained            = "XelonLoadBalancerRetained"
+	eventReasonLoadBalancerRetainFailed        = "XelonLoadBalancerRetainFailed"
+	eventReasonLoadBalancerProvisioningTimeout = "XelonLoadBalancerProvisioningTimeout"
+	eventReasonNodeDeleted                     = "XelonNodeDeleted"
+	eventReasonNodeRecreated                   = "XelonNodeRecreated"
+	eventReasonPodCIDRNotAvailable             = "XelonPodCIDRNotAvailable"
+	eventReasonUnknownAnnotations              = "XelonUnknownAnnotations"
+)
+
+func newEventRecorder(k8sClient kubernetes.Interface, stop <-chan struct{}) record.EventRecorder {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: k8sClient.CoreV1().Events("")})
+	go func() {
+		<-stop
+		broadcaster.Shutdown()
+	}()
+
+	return broadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: eventComponent})
+}
+
+// recordEventf publishes event for the object, it is a no-op if event recorder is not initialized.
+func (c *clients) recordEventf(object runtime.Object, eventType, reason, messageFmt string, args ...interface{}) {
+	if c == nil || c.recorder == nil {
+		return
+	}
+	c.recorder.Eventf(object, eventType, reason, messageFmt, args...)
+}
@@ -0,0 +1,125 @@
+package xelon
+
+import (
+	"time"
+
+	"github.com/spf13/pflag"
+	cliflag "k8s.io/component-base/cli/flag"
+)
+
+// flagSetName is the name of the flag set with Xelon specific flags in --help output.
+const flagSetName = "xelon"
+
+// commandLineFlags holds Xelon flags registered via AddFlags and applied to
+// the cloud config when the cloud provider is initialized.
+var commandLineFlags = &flags{}
+
+// flags represents Xelon specific command-line flags. Flag defaults match the
+// defaults of the cloud config for accurate --help output, but only explicitly
+// set flags are applied, so defaults never override the cloud config file.
+// Set flags take precedence over environment variables and the config file.
+type flags struct {
+	fs *pflag.FlagSet
+
+	baseURL      string
+	tokenFile    string
+	clientIDFile string
+
+	cloudID             string
+	kubernetesClusterID string
+
+	instancesCacheTTL              time.Duration
+	instancesDeviceFallback        bool
+	lbRetryInterval                time.Duration
+	lbProvisioningRetryInterval    time.Duration
+	lbProvisioningMaxRetryInterval time.Duration
+	lbProvisioningTimeout          time.Duration
+	lbDryRun                       bool
+
+	enableLoadBalancers bool
+	enableRoutes        bool
+	routesNetworkID     string
+	nodeIPAMNetworkID   string
+}
+
+// AddFlags registers Xelon specific flags as "xelon" flag set.
+func AddFlags(fss *cliflag.NamedFlagSets) {
+	commandLineFlags.addFlags(fss.FlagSet(flagSetName))
+}
+
+func (f *flags) addFlags(fs *pflag.FlagSet) {
+	f.fs = fs
+
+	fs.StringVar(&f.baseURL, "xelon-base-url", "", "Xelon API base URL, overrides api.baseURL of the cloud config and XELON_BASE_URL.")
+	fs.StringVar(&f.tokenFile, "xelon-token-file", "", "Path to the file with Xelon API token, overrides credentials.tokenFile of the cloud config and takes precedence over XELON_TOKEN.")
+	fs.StringVar(&f.clientIDFile, "xelon-client-id-file", "", "Path to the file with Xelon client id, overrides credentials.clientIDFile of the cloud config and takes precedence over XELON_CLIENT_ID.")
+	fs.StringVar(&f.cloudID, "xelon-cloud-id", "", "Xelon cloud id, overrides cloudID of the cloud config and XELON_CLOUD_ID.")
+	fs.StringVar(&f.kubernetesClusterID, "xelon-kubernetes-cluster-id", "", "Xelon Kubernetes cluster id, overrides kubernetesClusterID of the cloud config and XELON_KUBERNETES_CLUSTER_ID.")
+	fs.DurationVar(&f.instancesCacheTTL, "xelon-instances-cache-ttl", defaultInstancesCacheTTL, "How often nodes are refreshed from Xelon API, overrides instances.cacheTTL of the cloud config.")
+	fs.BoolVar(&f.instancesDeviceFallback, "xelon-instances-device-fallback", true, "Resolve nodes, which are not part of the Kubernetes cluster in Xelon, via Xelon devices, overrides instances.deviceFallback of the cloud config.")
+	fs.DurationVar(&f.lbRetryInterval, "xelon-lb-retry-interval", defaultLoadBalancersRetryInterval, "When services are retried while Xelon API is not reachable, overrides loadBalancers.retryInterval of the cloud config.")
+	fs.DurationVar(&f.lbProvisioningRetryInterval, "xelon-lb-provisioning-retry-interval", defaultLoadBalancersProvisioningRetryInterval, "When services are retried while load balancer cluster is being provisioned, overrides loadBalancers.provisioningRetryInterval of the cloud config.")
+	fs.DurationVar(&f.lbProvisioningMaxRetryInterval, "xelon-lb-provisioning-max-retry-interval", defaultLoadBalancersProvisioningMaxRetryInterval, "Maximum interval between retries while load balancer cluster is not active, overrides loadBalancers.provisioningMaxRetryInterval of the cloud config.")
+	fs.DurationVar(&f.lbProvisioningTimeout, "xelon-lb-provisioning-timeout", defaultLoadBalancersProvisioningTimeout, "How long services wait for load balancer cluster to become active before giving up, overrides loadBalancers.provisioningTimeout of the cloud config.")
+	fs.BoolVar(&f.lbDryRun, "xelon-lb-dry-run", false, "Only log and publish events about planned load balancer changes, overrides loadBalancers.dryRun of the cloud config.")
+	fs.BoolVar(&f.enableLoadBalancers, "xelon-enable-load-balancers", true, "Enable load balancers, overrides features.loadBalancers of the cloud config.")
+	fs.BoolVar(&f.enableRoutes, "xelon-enable-routes", false, "Enable routes on Xelon private network, overrides features.routes of the cloud config.")
+	fs.StringVar(&f.routesNetworkID, "xelon-routes-network-id", "", "Xelon private network id, on which routes are created, overrides routes.networkID of the cloud config. Required if routes are enabled.")
+	fs.StringVar(&f.nodeIPAMNetworkID, "xelon-node-ipam-network-id", "", "Xelon private network id with ranges reserved for pods, overrides nodeIPAM.networkID of the cloud config. Required to run xelon-node-ipam controller.")
+}
+
+// apply overrides cloud config with explicitly set flags.
+func (f *flags) apply(cfg *cloudConfig) {
+	if f.fs == nil {
+		return
+	}
+
+	if f.fs.Changed("xelon-base-url") {
+		cfg.API.BaseURL = f.baseURL
+	}
+	if f.fs.Changed("xelon-token-file") {
+		cfg.Credentials.TokenFile = f.tokenFile
+	}
+	if f.fs.Changed("xelon-client-id-file") {
+		cfg.Credentials.ClientIDFile = f.clientIDFile
+	}
+	if f.fs.Changed("xelon-cloud-id") {
+		cfg.CloudID = f.cloudID
+	}
+	if f.fs.Changed("xelon-kubernetes-cluster-id") {
+		cfg.KubernetesClusterID = f.kubernetesClusterID
+	}
+	if f.fs.Changed("xelon-instances-cache-ttl") {
+		cfg.Instances.CacheTTL.Duration = f.instancesCacheTTL
+	}
+	if f.fs.Changed("xelon-instances-device-fallback") {
+		cfg.Instances.DeviceFallback = f.instancesDeviceFallback
+	}
+	if f.fs.Changed("xelon-lb-retry-interval") {
+		cfg.LoadBalancers.RetryInterval.Duration = f.lbRetryInterval
+	}
+	if f.fs.Changed("xelon-lb-provisioning-retry-interval") {
+		cfg.LoadBalancers.ProvisioningRetryInterval.Duration = f.lbProvisioningRetryInterval
+	}
+	if f.fs.Changed("xelon-lb-provisioning-max-retry-interval") {
+		cfg.LoadBalancers.ProvisioningMaxRetryInterval.Duration = f.lbProvisioningMaxRetryInterval
+	}
+	if f.fs.Changed("xelon-lb-provisioning-timeout") {
+		cfg.LoadBalancers.ProvisioningTimeout.Duration = f.lbProvisioningTimeout
+	}
+	if f.fs.Changed("xelon-lb-dry-run") {
+		cfg.LoadBalancers.DryRun = f.lbDryRun
+	}
+	if f.fs.Changed("xelon-enable-load-balancers") {
+		cfg.Features.LoadBalancers = f.enableLoadBalancers
+	}
+	if f.fs.Changed("xelon-enable-routes") {
+		cfg.Features.Routes = f.enableRoutes
+	}
+	if f.fs.Changed("xelon-routes-network-id") {
+		cfg.Routes.NetworkID = f.routesNetworkID
+	}
+	if f.fs.Changed("xelon-node-ipam-network-id") {
+		cfg.NodeIPAM.NetworkID = f.nodeIPAMNetworkID
+	}
+}
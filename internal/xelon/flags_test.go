@@ -0,0 +1,101 @@
+package xelon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlags_apply(t *testing.T) {
+	type testCase struct {
+		args     []string
+		expected func(cfg *cloudConfig)
+	}
+	tests := map[string]testCase{
+		"no flags": {
+			args:     []string{},
+			expected: func(cfg *cloudConfig) {},
+		},
+		"all flags": {
+			args: []string{
+				"--xelon-base-url=https://example.com/api/",
+				"--xelon-token-file=/etc/xelon/token",
+				"--xelon-client-id-file=/etc/xelon/client-id",
+				"--xelon-cloud-id=cloud-id",
+				"--xelon-kubernetes-cluster-id=cluster-id",
+				"--xelon-instances-cache-ttl=1m",
+				"--xelon-instances-device-fallback=false",
+				"--xelon-lb-retry-interval=10s",
+				"--xelon-lb-provisioning-retry-interval=2m",
+				"--xelon-lb-provisioning-max-retry-interval=10m",
+				"--xelon-lb-provisioning-timeout=1h",
+				"--xelon-lb-dry-run",
+				"--xelon-enable-load-balancers=false",
+				"--xelon-enable-routes",
+				"--xelon-routes-network-id=routes-network-id",
+				"--xelon-node-ipam-network-id=ipam-network-id",
+			},
+			expected: func(cfg *cloudConfig) {
+				cfg.API.BaseURL = "https://example.com/api/"
+				cfg.Credentials.TokenFile = "/etc/xelon/token"
+				cfg.Credentials.ClientIDFile = "/etc/xelon/client-id"
+				cfg.CloudID = "cloud-id"
+				cfg.KubernetesClusterID = "cluster-id"
+				cfg.Instances.CacheTTL.Duration = time.Minute
+				cfg.Instances.DeviceFallback = false
+				cfg.LoadBalancers.RetryInterval.Duration = 10 * time.Second
+				cfg.LoadBalancers.ProvisioningRetryInterval.Duration = 2 * time.Minute
+				cfg.LoadBalancers.ProvisioningMaxRetryInterval.Duration = 10 * time.Minute
+				cfg.LoadBalancers.ProvisioningTimeout.Duration = time.Hour
+				cfg.LoadBalancers.DryRun = true
+				cfg.Features.LoadBalancers = false
+				cfg.Features.Routes = true
+				cfg.Routes.NetworkID = "routes-network-id"
+				cfg.NodeIPAM.NetworkID = "ipam-network-id"
+			},
+		},
+		"only set flags override": {
+			args: []string{"--xelon-cloud-id=flag-cloud-id"},
+			expected: func(cfg *cloudConfig) {
+				cfg.CloudID = "flag-cloud-id"
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			f := &flags{}
+			fs := pflag.NewFlagSet(name, pflag.ContinueOnError)
+			f.addFlags(fs)
+			assert.NoError(t, fs.Parse(test.args))
+
+			cfg := defaultCloudConfig()
+			cfg.CloudID = "config-cloud-id"
+			cfg.KubernetesClusterID = "config-cluster-id"
+			f.apply(cfg)
+
+			expected := defaultCloudConfig()
+			expected.CloudID = "config-cloud-id"
+			expected.KubernetesClusterID = "config-cluster-id"
+			test.expected(expected)
+			assert.Equal(t, expected, cfg)
+		})
+	}
+}
+
+func TestFlags_defaults(t *testing.T) {
+	f := &flags{}
+	fs := pflag.NewFlagSet("defaults", pflag.ContinueOnError)
+	f.addFlags(fs)
+	// explicitly set every flag to its default shown in --help
+	fs.VisitAll(func(flag *pflag.Flag) {
+		assert.NoError(t, fs.Set(flag.Name, flag.DefValue))
+	})
+
+	cfg := defaultCloudConfig()
+	f.apply(cfg)
+
+	assert.Equal(t, defaultCloudConfig(), cfg)
+}
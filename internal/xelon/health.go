@@ -0,0 +1,87 @@
+package xelon
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+)
+
+const (
+	apiHealthCheckResultSuccess      = "success"
+	apiHealthCheckResultUnauthorized = "unauthorized"
+	apiHealthCheckResultError        = "error"
+)
+
+// apiHealthChecker periodically validates Xelon credentials and reachability
+// of Xelon API. The result is exposed as healthz check and as metrics.
+type apiHealthChecker struct {
+	clients  *clients
+	tenant   *tenantResolver
+	baseURL  string
+	interval time.Duration
+
+	mu        sync.RWMutex
+	lastCheck time.Time
+	lastErr   error
+}
+
+func newAPIHealthChecker(clients *clients, tenant *tenantResolver, baseURL string, interval time.Duration) *apiHealthChecker {
+	return &apiHealthChecker{
+		clients:  clients,
+		tenant:   tenant,
+		baseURL:  baseURL,
+		interval: interval,
+	}
+}
+
+func (h *apiHealthChecker) run(ctx context.Context) {
+	registerMetrics()
+	klog.InfoS("Starting Xelon API health checks", "base_url", h.baseURL, "interval", h.interval)
+	wait.UntilWithContext(ctx, h.check, h.interval)
+}
+
+func (h *apiHealthChecker) check(ctx context.Context) {
+	var result string
+	_, resp, err := h.clients.xelon().Tenants.GetCurrent(ctx)
+	switch {
+	case err == nil:
+		result = apiHealthCheckResultSuccess
+	case resp != nil && (resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden):
+		result = apiHealthCheckResultUnauthorized
+		err = fmt.Errorf("xelon api rejected credentials (status: %d): %w", resp.StatusCode, err)
+	default:
+		result = apiHealthCheckResultError
+		err = fmt.Errorf("xelon api is not reachable: %w", err)
+	}
+
+	apiHealthChecksTotal.WithLabelValues(result).Inc()
+	if err != nil {
+		apiUp.Set(0)
+		klog.ErrorS(err, "Xelon API health check failed")
+	} else {
+		apiUp.Set(1)
+		klog.V(5).InfoS("Xelon API health check succeeded")
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastCheck = time.Now()
+	h.lastErr = err
+}
+
+// Check implements healthz check. It fails until tenant is resolved and
+// whenever the last periodic check failed.
+func (h *apiHealthChecker) Check(req *http.Request) error {
+	if err := h.tenant.Check(req); err != nil {
+		return err
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.lastErr
+}
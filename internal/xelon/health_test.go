@@ -0,0 +1,65 @@
+package xelon
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Xelon-AG/xelon-sdk-go/xelon"
+)
+
+func TestAPIHealthChecker_check(t *testing.T) {
+	var statusCode atomic.Int32
+	statusCode.Store(http.StatusOK)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(int(statusCode.Load()))
+		_, _ = w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	c := newClients(xelon.NewClient("token", xelon.WithBaseURL(server.URL+"/")))
+	tenant := newTenantResolver(c)
+	h := newAPIHealthChecker(c, tenant, server.URL+"/", time.Minute)
+
+	assert.ErrorIs(t, h.Check(nil), errTenantNotResolved)
+
+	_, err := tenant.tenantID(context.Background())
+	assert.NoError(t, err)
+
+	type testCase struct {
+		statusCode  int
+		expectedErr string
+	}
+	tests := map[string]testCase{
+		"healthy": {
+			statusCode: http.StatusOK,
+		},
+		"expired token": {
+			statusCode:  http.StatusUnauthorized,
+			expectedErr: "xelon api rejected credentials (status: 401)",
+		},
+		"unavailable": {
+			statusCode:  http.StatusBadGateway,
+			expectedErr: "xelon api is not reachable",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			statusCode.Store(int32(test.statusCode))
+
+			h.check(context.Background())
+
+			if test.expectedErr == "" {
+				assert.NoError(t, h.Check(nil))
+			} else {
+				assert.ErrorContains(t, h.Check(nil), test.expectedErr)
+			}
+		})
+	}
+}
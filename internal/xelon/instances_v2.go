@@ -4,13 +4,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
+	"net/http"
 	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
 	cloudprovider "k8s.io/cloud-provider"
+	cloudproviderapi "k8s.io/cloud-provider/api"
 	"k8s.io/klog/v2"
 
 	"github.com/Xelon-AG/xelon-sdk-go/xelon"
@@ -18,55 +23,254 @@ import (
 
 const providerIDPrefix = ProviderName + "://"
 
+const (
+	// nodeLabelNodePoolID is set to the id of Xelon node pool the node belongs to.
+	nodeLabelNodePoolID = "kubernetes.xelon.ch/node-pool-id"
+
+	// nodeLabelNodePoolName is set to the name of Xelon node pool the node belongs to.
+	nodeLabelNodePoolName = "kubernetes.xelon.ch/node-pool-name"
+
+	// nodeLabelControlPlane is set to "true" on Xelon control plane nodes.
+	nodeLabelControlPlane = "kubernetes.xelon.ch/control-plane"
+
+	// nodeLabelCPUCores is set to the number of CPU cores of Xelon VM.
+	nodeLabelCPUCores = "kubernetes.xelon.ch/cpu-cores"
+
+	// nodeLabelMemoryGB is set to the memory of Xelon VM in gigabytes.
+	nodeLabelMemoryGB = "kubernetes.xelon.ch/memory-gb"
+
+	// nodeLabelDiskGB is set to the disk size of Xelon VM in gigabytes.
+	nodeLabelDiskGB = "kubernetes.xelon.ch/disk-gb"
+)
+
 var _ cloudprovider.InstancesV2 = (*instances)(nil)
 
 type xelonNode struct {
-	localVMID string
-	name      string
-	nodeType  string
+	localVMID    string
+	name         string
+	nodeType     string
+	nodePoolID   string
+	nodePoolName string
+	controlPlane bool
+	cpuCores     int
+	memoryGB     int
+	diskGB       int
+
+	// device is set if the node was just resolved via Xelon device, so it
+	// is not fetched again
+	device *xelonDevice
 }
 
+// instances caches Xelon nodes indexed by local VM id and by name. The cache
+// is refreshed in the background every ttl and additionally once on cache
+// miss, so newly joined nodes are found immediately. If Xelon API fails, the
+// last successfully fetched nodes are served up to maxStaleness. Nodes, which
+// are not part of the Kubernetes cluster in Xelon, are resolved via Xelon
+// devices if deviceFallback is enabled. Found devices are cached up to
+// maxStaleness, missing devices are cached for ttl.
 type instances struct {
-	client    *clients
-	clusterID string
-
-	nodes      []xelonNode
-	lastUpdate time.Time
-	ttl        time.Duration
+	client         *clients
+	tenant         *tenantResolver
+	cloudID        string
+	clusterID      string
+	ttl            time.Duration
+	maxStaleness   time.Duration
+	deviceFallback bool
+
+	// refreshMu serializes refreshes, so concurrent cache misses result
+	// in a single call to Xelon API.
+	refreshMu sync.Mutex
+
+	mu               sync.RWMutex
+	nodesByLocalVMID map[string]xelonNode
+	nodesByName      map[string]xelonNode
+	lastUpdate       time.Time
+	deviceLookups    map[string]deviceLookup
+}
 
-	sync.RWMutex
+// deviceLookup is a cached result of resolving a node via Xelon devices,
+// node is nil if no device was found.
+type deviceLookup struct {
+	node      *xelonNode
+	fetchedAt time.Time
 }
 
-func newInstances(clients *clients, clusterID string) cloudprovider.InstancesV2 {
+func newInstances(clients *clients, tenant *tenantResolver, cloudID, clusterID string, config instancesConfig) *instances {
 	return &instances{
-		client:    clients,
-		clusterID: clusterID,
-
-		nodes: make([]xelonNode, 0),
-		ttl:   15 * time.Second,
+		client:         clients,
+		tenant:         tenant,
+		cloudID:        cloudID,
+		clusterID:      clusterID,
+		ttl:            config.CacheTTL.Duration,
+		maxStaleness:   config.MaxStaleness.Duration,
+		deviceFallback: config.DeviceFallback || clusterID == "",
 	}
 }
 
+// run refreshes the cache in the background every ttl until ctx is cancelled.
+func (i *instances) run(ctx context.Context) {
+	registerMetrics()
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := i.refreshNodes(ctx); err != nil {
+			klog.ErrorS(err, "Failed to refresh nodes from Xelon API")
+		}
+	}, i.ttl)
+}
+
 func (i *instances) InstanceExists(ctx context.Context, node *v1.Node) (bool, error) {
 	_, err := i.lookupXelonNode(ctx, node)
 	if err != nil {
 		if errors.Is(err, cloudprovider.InstanceNotFound) {
-			return false, nil
+			return i.confirmInstanceExists(ctx, node)
 		}
 		return false, err
 	}
 	return true, nil
 }
 
+// confirmInstanceExists double-checks a node, which is missing in cached nodes,
+// before it is reported as non-existent and deleted by node lifecycle controller:
+// nodes are refreshed once more and the VM is looked up directly by its local
+// VM id or, if not set, by node name, so partial or eventually-consistent API
+// responses are tolerated.
+func (i *instances) confirmInstanceExists(ctx context.Context, node *v1.Node) (bool, error) {
+	if err := i.refreshNodes(ctx); err != nil {
+		return false, fmt.Errorf("failed to confirm absence of node %s: %w", node.Name, err)
+	}
+	_, err := i.getCachedXelonNode(node)
+	if err == nil {
+		klog.InfoS("Node found after consecutive refresh", "node", node.Name)
+		return true, nil
+	}
+	if !errors.Is(err, cloudprovider.InstanceNotFound) {
+		return false, err
+	}
+
+	tenantID, err := i.tenant.tenantID(ctx)
+	if err != nil {
+		return false, err
+	}
+	localVMID := ""
+	if isXelonProviderID(node.Spec.ProviderID) {
+		localVMID, _ = parseProviderID(node.Spec.ProviderID)
+	}
+	if localVMID == "" {
+		// node is not initialized yet, so its VM is looked up by name
+		device, err := findXelonDevice(ctx, i.client.xelon(), tenantID, node.Name)
+		if err != nil {
+			return false, fmt.Errorf("failed to confirm absence of node %s: %w", node.Name, err)
+		}
+		if device != nil {
+			klog.InfoS("Xelon VM exists but is not listed in cluster nodes, keep node", "node", node.Name, "local_vm_id", device.LocalVMID)
+			return true, nil
+		}
+	}
+	if localVMID != "" {
+		_, resp, err := getXelonDevice(ctx, i.client.xelon(), tenantID, localVMID)
+		if err == nil {
+			klog.InfoS("Xelon VM exists but is not listed in cluster nodes, keep node", "node", node.Name, "local_vm_id", localVMID)
+			return true, nil
+		}
+		if resp == nil || resp.StatusCode != http.StatusNotFound {
+			return false, fmt.Errorf("failed to confirm absence of node %s: %w", node.Name, err)
+		}
+	}
+
+	if localVMID != "" {
+		recreated, err := i.findRecreatedXelonNode(ctx, node.Name, localVMID)
+		if err != nil {
+			return false, fmt.Errorf("failed to confirm absence of node %s: %w", node.Name, err)
+		}
+		if recreated != nil {
+			// the node is deleted, so kubelet registers it again and it is
+			// initialized with provider id of the new VM
+			klog.InfoS("Xelon VM was recreated under the same name, node is considered deleted",
+				"node", node.Name, "local_vm_id", localVMID, "new_local_vm_id", recreated.localVMID)
+			i.client.recordEventf(node, v1.EventTypeWarning, eventReasonNodeRecreated,
+				"Xelon VM %s of node %s was replaced by VM %s with the same name, node is considered deleted and registers again",
+				localVMID, node.Name, recreated.localVMID)
+			return false, nil
+		}
+	}
+
+	klog.InfoS("Xelon VM not found, node is considered deleted", "node", node.Name, "local_vm_id", localVMID)
+	i.client.recordEventf(node, v1.EventTypeNormal, eventReasonNodeDeleted,
+		"Xelon VM of node %s not found after consecutive refreshes and direct lookup, node is considered deleted", node.Name)
+
+	return false, nil
+}
+
+// findRecreatedXelonNode returns node with the given name but different local
+// VM id, e.g. if node pool replaced the VM and reused its hostname, or nil if
+// there is no such node.
+func (i *instances) findRecreatedXelonNode(ctx context.Context, name, localVMID string) (*xelonNode, error) {
+	xn, err := i.getXelonNodeByName(name)
+	if err == nil && xn.localVMID != localVMID {
+		return xn, nil
+	}
+	if !i.deviceFallback {
+		return nil, nil
+	}
+
+	tenantID, err := i.tenant.tenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	device, err := findXelonDevice(ctx, i.client.xelon(), tenantID, name)
+	if err != nil {
+		return nil, err
+	}
+	if device != nil && device.LocalVMID != localVMID {
+		return newXelonNodeFromDevice(device), nil
+	}
+
+	return nil, nil
+}
+
+// InstanceShutdown reports stopped or suspended Xelon VMs as shut down, so
+// node lifecycle controller can taint the node and move its workloads.
 func (i *instances) InstanceShutdown(ctx context.Context, node *v1.Node) (bool, error) {
-	_, err := i.lookupXelonNode(ctx, node)
+	xn, err := i.lookupXelonNode(ctx, node)
 	if err != nil {
 		if errors.Is(err, cloudprovider.InstanceNotFound) {
 			return false, nil
 		}
 		return false, err
 	}
-	return false, nil
+
+	tenantID, err := i.tenant.tenantID(ctx)
+	if err != nil {
+		return false, err
+	}
+	device, resp, err := getXelonDevice(ctx, i.client.xelon(), tenantID, xn.localVMID)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			// absence of the VM is handled by InstanceExists
+			return false, nil
+		}
+		return false, err
+	}
+
+	shutdown := isDeviceShutDown(device)
+	klog.V(5).InfoS("Got power state from Xelon API", "node", node.Name, "local_vm_id", xn.localVMID, "state", device.State, "shutdown", shutdown)
+
+	return shutdown, nil
+}
+
+// isDeviceShutDown maps state of the device to shutdown: stopped and suspended
+// devices are shut down. If the state is unknown, the power state is used and
+// devices without any of them are considered running, so they are not tainted.
+func isDeviceShutDown(device *xelonDevice) bool {
+	switch strings.ToLower(device.State) {
+	case "stopped", "poweredoff", "suspended":
+		return true
+	case "running", "poweredon":
+		return false
+	}
+	if device.PowerState != nil {
+		return !*device.PowerState
+	}
+	return false
 }
 
 func (i *instances) InstanceMetadata(ctx context.Context, node *v1.Node) (*cloudprovider.InstanceMetadata, error) {
@@ -81,58 +285,255 @@ func (i *instances) InstanceMetadata(ctx context.Context, node *v1.Node) (*cloud
 	}
 
 	meta.ProviderID = fmt.Sprintf("%s%s", providerIDPrefix, xn.localVMID)
-	meta.NodeAddresses = node.Status.Addresses
 	meta.InstanceType = xn.nodeType
+	meta.AdditionalLabels = buildNodeLabels(xn)
+
+	device, err := i.getNodeDevice(ctx, xn)
+	if err != nil {
+		if !isNodeInitialized(node) {
+			// topology and instance type labels are only applied while the
+			// node is initialized, so initialization has to be retried
+			return nil, fmt.Errorf("failed to get Xelon device of node %s: %w", node.Name, err)
+		}
+		// node is initialized already, so addresses reported by kubelet are
+		// kept until the device can be fetched again
+		klog.ErrorS(err, "Failed to get Xelon device, keep addresses reported by kubelet", "node", node.Name, "local_vm_id", xn.localVMID)
+		meta.NodeAddresses = node.Status.Addresses
+		return meta, nil
+	}
+
+	meta.NodeAddresses = buildNodeAddresses(device, xn.name)
+	if !slices.ContainsFunc(meta.NodeAddresses, isNodeIPAddress) {
+		klog.InfoS("No IP addresses reported by Xelon API, keep addresses reported by kubelet", "node", node.Name)
+		meta.NodeAddresses = node.Status.Addresses
+	}
+	if device.Product != nil && device.Product.Name != "" {
+		meta.InstanceType = sanitizeLabelValue(device.Product.Name)
+	}
+	meta.Region, meta.Zone = getTopology(device, i.cloudID)
 
 	klog.V(5).InfoS("Setting instance metadata for node", "node", node.Name, "metadata", meta)
 
 	return meta, nil
 }
 
-func (i *instances) lookupXelonNode(ctx context.Context, node *v1.Node) (*xelonNode, error) {
-	err := i.refreshNodes(ctx)
+// getNodeDevice returns Xelon device of the node, it is fetched from Xelon API
+// unless the node was just resolved via the device.
+func (i *instances) getNodeDevice(ctx context.Context, xn *xelonNode) (*xelonDevice, error) {
+	if xn.device != nil {
+		return xn.device, nil
+	}
+	tenantID, err := i.tenant.tenantID(ctx)
 	if err != nil {
 		return nil, err
 	}
+	device, _, err := getXelonDevice(ctx, i.client.xelon(), tenantID, xn.localVMID)
+	return device, err
+}
 
-	providerID := node.Spec.ProviderID
-	if providerID != "" && isXelonProviderID(providerID) {
-		klog.V(5).InfoS("Use providerID to get Xelon node", "provider_id", providerID)
+func (i *instances) lookupXelonNode(ctx context.Context, node *v1.Node) (*xelonNode, error) {
+	xn, err := i.lookupClusterNode(ctx, node)
+	if errors.Is(err, cloudprovider.InstanceNotFound) && i.deviceFallback {
+		klog.V(4).InfoS("Node is not part of Kubernetes cluster, looking up Xelon device", "node", node.Name)
+		return i.lookupXelonDevice(ctx, node)
+	}
+	return xn, err
+}
 
-		localVMID, err := parseProviderID(providerID)
+// lookupClusterNode looks up control plane and node pool nodes of Kubernetes cluster.
+func (i *instances) lookupClusterNode(ctx context.Context, node *v1.Node) (*xelonNode, error) {
+	i.mu.RLock()
+	lastUpdate := i.lastUpdate
+	i.mu.RUnlock()
+
+	if lastUpdate.IsZero() || time.Since(lastUpdate) > i.maxStaleness {
+		// never serve nodes older than maxStaleness, API errors are returned
+		// instead, so the node is not considered to be gone
+		if err := i.refreshNodesSince(ctx, lastUpdate); err != nil {
+			return nil, fmt.Errorf("failed to refresh nodes (last update: %v): %w", lastUpdate, err)
+		}
+		return i.getCachedXelonNode(node)
+	}
+
+	xn, err := i.getCachedXelonNode(node)
+	if !errors.Is(err, cloudprovider.InstanceNotFound) {
+		return xn, err
+	}
+
+	klog.V(4).InfoS("Node is not cached, refreshing nodes", "node", node.Name)
+	if err := i.refreshNodesSince(ctx, lastUpdate); err != nil {
+		return nil, fmt.Errorf("node %s is not cached and refreshing nodes failed: %w", node.Name, err)
+	}
+	return i.getCachedXelonNode(node)
+}
+
+// lookupXelonDevice resolves node via Xelon devices by provider id or, if not
+// set, by hostname.
+func (i *instances) lookupXelonDevice(ctx context.Context, node *v1.Node) (*xelonNode, error) {
+	key := node.Name
+	if isXelonProviderID(node.Spec.ProviderID) {
+		key = node.Spec.ProviderID
+	}
+	if lookup, ok := i.getCachedDeviceLookup(key); ok {
+		klog.V(5).InfoS("Use cached Xelon device lookup", "node", node.Name, "found", lookup.node != nil)
+		if lookup.node == nil {
+			return nil, cloudprovider.InstanceNotFound
+		}
+		xn := *lookup.node
+		return &xn, nil
+	}
+
+	xn, err := i.fetchXelonDevice(ctx, node)
+	switch {
+	case err == nil:
+		i.storeDeviceLookup(key, xn)
+	case errors.Is(err, cloudprovider.InstanceNotFound):
+		i.storeDeviceLookup(key, nil)
+	}
+	return xn, err
+}
+
+// fetchXelonDevice fetches device of the node from Xelon API.
+func (i *instances) fetchXelonDevice(ctx context.Context, node *v1.Node) (*xelonNode, error) {
+	tenantID, err := i.tenant.tenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var device *xelonDevice
+	if isXelonProviderID(node.Spec.ProviderID) {
+		localVMID, err := parseProviderID(node.Spec.ProviderID)
 		if err != nil {
 			return nil, err
 		}
-		xn, err := i.getXelonNodeByLocalVMID(localVMID)
+		var resp *xelon.Response
+		device, resp, err = getXelonDevice(ctx, i.client.xelon(), tenantID, localVMID)
 		if err != nil {
+			if resp != nil && resp.StatusCode == http.StatusNotFound {
+				return nil, cloudprovider.InstanceNotFound
+			}
 			return nil, err
 		}
-		return xn, nil
 	} else {
-		klog.V(5).InfoS("Use name to get Xelon node", "name", node.Name)
+		device, err = findXelonDevice(ctx, i.client.xelon(), tenantID, node.Name)
+		if err != nil {
+			return nil, err
+		}
+		if device == nil {
+			return nil, cloudprovider.InstanceNotFound
+		}
+	}
+
+	return newXelonNodeFromDevice(device), nil
+}
+
+// getCachedDeviceLookup returns cached device lookup unless it is expired.
+func (i *instances) getCachedDeviceLookup(key string) (deviceLookup, bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	lookup, ok := i.deviceLookups[key]
+	if !ok {
+		return deviceLookup{}, false
+	}
+	maxAge := i.maxStaleness
+	if lookup.node == nil {
+		maxAge = i.ttl
+	}
+	return lookup, time.Since(lookup.fetchedAt) <= maxAge
+}
+
+func (i *instances) storeDeviceLookup(key string, xn *xelonNode) {
+	if xn != nil {
+		// cached nodes never serve outdated devices
+		cached := *xn
+		cached.device = nil
+		xn = &cached
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
 
-		xn, err := i.getXelonNodeByName(node.Name)
+	if i.deviceLookups == nil {
+		i.deviceLookups = make(map[string]deviceLookup)
+	}
+	i.deviceLookups[key] = deviceLookup{node: xn, fetchedAt: time.Now()}
+}
+
+// getCachedXelonNode gets cached node by provider id or, if not set, by name.
+func (i *instances) getCachedXelonNode(node *v1.Node) (*xelonNode, error) {
+	providerID := node.Spec.ProviderID
+	if providerID != "" && isXelonProviderID(providerID) {
+		klog.V(5).InfoS("Use providerID to get Xelon node", "provider_id", providerID)
+
+		localVMID, err := parseProviderID(providerID)
 		if err != nil {
 			return nil, err
 		}
-		return xn, nil
+		return i.getXelonNodeByLocalVMID(localVMID)
+	} else {
+		klog.V(5).InfoS("Use name to get Xelon node", "name", node.Name)
+
+		return i.getXelonNodeByName(node.Name)
 	}
 }
 
-// refreshNodes conditionally loads all control plane and node pool nodes from Xelon API
-// and caches them. It does not refresh if the last update happened less than 'nodeCache.ttl' ago.
+// refreshNodesSince refreshes nodes unless they have been refreshed after
+// lastUpdate already (e.g. by a concurrent cache miss).
+func (i *instances) refreshNodesSince(ctx context.Context, lastUpdate time.Time) error {
+	i.refreshMu.Lock()
+	defer i.refreshMu.Unlock()
+
+	i.mu.RLock()
+	refreshed := i.lastUpdate.After(lastUpdate)
+	i.mu.RUnlock()
+	if refreshed {
+		return nil
+	}
+
+	err := i.fetchNodes(ctx)
+	i.recordRefresh(err)
+	return err
+}
+
+// refreshNodes loads all control plane and node pool nodes from Xelon API and
+// replaces cached nodes.
 func (i *instances) refreshNodes(ctx context.Context) error {
-	i.Lock()
-	defer i.Unlock()
+	i.refreshMu.Lock()
+	defer i.refreshMu.Unlock()
 
-	sinceLastUpdate := time.Since(i.lastUpdate)
-	if sinceLastUpdate < i.ttl {
-		klog.V(2).InfoS("Skip refreshing nodes", "since_last_update", sinceLastUpdate, "ttl", i.ttl)
+	err := i.fetchNodes(ctx)
+	i.recordRefresh(err)
+	return err
+}
+
+// recordRefresh updates cache metrics after a refresh attempt. On failure,
+// cached nodes are kept and served until they become older than maxStaleness.
+func (i *instances) recordRefresh(err error) {
+	i.mu.RLock()
+	lastUpdate := i.lastUpdate
+	i.mu.RUnlock()
+
+	if err != nil {
+		instancesCacheRefreshFailuresTotal.Inc()
+		if !lastUpdate.IsZero() {
+			klog.InfoS("Serving cached nodes until Xelon API is reachable again", "last_update", lastUpdate, "max_staleness", i.maxStaleness)
+		}
+	}
+	if !lastUpdate.IsZero() {
+		instancesCacheAgeSeconds.Set(time.Since(lastUpdate).Seconds())
+	}
+}
+
+func (i *instances) fetchNodes(ctx context.Context) error {
+	if i.clusterID == "" {
+		// not an XKS cluster, all nodes are resolved via Xelon devices
+		i.storeNodes(nil)
 		return nil
 	}
 
 	klog.V(5).InfoS("Getting control planes from Xelon API", "cluster_id", i.clusterID)
-	controlPlane, _, err := i.client.xelon.Kubernetes.ListControlPlane(ctx, i.clusterID)
+	controlPlane, _, err := i.client.xelon().Kubernetes.ListControlPlane(ctx, i.clusterID)
 	if err != nil {
 		return err
 	}
@@ -140,14 +541,18 @@ func (i *instances) refreshNodes(ctx context.Context) error {
 	var controlPlaneNodes []xelonNode
 	for _, controlPlaneNode := range controlPlane.Nodes {
 		controlPlaneNodes = append(controlPlaneNodes, xelonNode{
-			localVMID: controlPlaneNode.LocalVMID,
-			name:      controlPlaneNode.Name,
-			nodeType:  getNodeTypeFromControlPlaneNode(controlPlane),
+			localVMID:    controlPlaneNode.LocalVMID,
+			name:         controlPlaneNode.Name,
+			nodeType:     getNodeTypeFromControlPlaneNode(controlPlane),
+			controlPlane: true,
+			cpuCores:     controlPlane.CPUCores,
+			memoryGB:     controlPlane.RAM,
+			diskGB:       controlPlane.DiskSize,
 		})
 	}
 
 	klog.V(5).InfoS("Getting node pools from Xelon API", "cluster_id", i.clusterID)
-	nodePools, _, err := i.client.xelon.Kubernetes.ListNodePools(ctx, i.clusterID)
+	nodePools, _, err := i.client.xelon().Kubernetes.ListNodePools(ctx, i.clusterID)
 	if err != nil {
 		return err
 	}
@@ -156,34 +561,63 @@ func (i *instances) refreshNodes(ctx context.Context) error {
 	for _, nodePool := range nodePools {
 		for _, nodePoolNode := range nodePool.Nodes {
 			nodePoolNodes = append(nodePoolNodes, xelonNode{
-				localVMID: nodePoolNode.LocalVMID,
-				name:      nodePoolNode.Name,
-				nodeType:  getNodeTypeFromNodePool(&nodePool),
+				localVMID:    nodePoolNode.LocalVMID,
+				name:         nodePoolNode.Name,
+				nodeType:     getNodeTypeFromNodePool(&nodePool),
+				nodePoolID:   nodePool.ID,
+				nodePoolName: nodePool.Name,
+				cpuCores:     nodePool.CPUCores,
+				memoryGB:     nodePool.RAM,
+				diskGB:       nodePool.DiskSize,
 			})
 		}
 	}
 
-	i.nodes = slices.Concat(controlPlaneNodes, nodePoolNodes)
-	i.lastUpdate = time.Now()
+	i.storeNodes(slices.Concat(controlPlaneNodes, nodePoolNodes))
 
 	return nil
 }
 
+func (i *instances) storeNodes(nodes []xelonNode) {
+	nodesByLocalVMID := make(map[string]xelonNode, len(nodes))
+	nodesByName := make(map[string]xelonNode, len(nodes))
+	for _, node := range nodes {
+		nodesByLocalVMID[node.localVMID] = node
+		nodesByName[node.name] = node
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.nodesByLocalVMID = nodesByLocalVMID
+	i.nodesByName = nodesByName
+	i.lastUpdate = time.Now()
+}
+
+// cachedNodes returns a snapshot of cached Xelon nodes.
+func (i *instances) cachedNodes() []xelonNode {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	return slices.Collect(maps.Values(i.nodesByLocalVMID))
+}
+
 func (i *instances) getXelonNodeByLocalVMID(localVMID string) (*xelonNode, error) {
-	for _, node := range i.nodes {
-		if node.localVMID == localVMID {
-			return &node, nil
-		}
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	if node, ok := i.nodesByLocalVMID[localVMID]; ok {
+		return &node, nil
 	}
 
 	return nil, cloudprovider.InstanceNotFound
 }
 
 func (i *instances) getXelonNodeByName(name string) (*xelonNode, error) {
-	for _, node := range i.nodes {
-		if node.name == name {
-			return &node, nil
-		}
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	if node, ok := i.nodesByName[name]; ok {
+		return &node, nil
 	}
 
 	return nil, cloudprovider.InstanceNotFound
@@ -198,7 +632,7 @@ func getNodeTypeFromControlPlaneNode(controlPlane *xelon.KubernetesClusterContro
 	if controlPlane == nil {
 		return ""
 	}
-	return fmt.Sprintf("c%dc-m%dg-d%dg", controlPlane.CPUCores, controlPlane.RAM, controlPlane.DiskSize)
+	return formatNodeType(controlPlane.CPUCores, controlPlane.RAM, controlPlane.DiskSize)
 }
 
 // getNodeTypeFromNodePool formats a node type from node pool parameters
@@ -210,7 +644,65 @@ func getNodeTypeFromNodePool(nodePool *xelon.KubernetesClusterNodePool) string {
 	if nodePool == nil {
 		return ""
 	}
-	return fmt.Sprintf("c%dc-m%dg-d%dg", nodePool.CPUCores, nodePool.RAM, nodePool.DiskSize)
+	return formatNodeType(nodePool.CPUCores, nodePool.RAM, nodePool.DiskSize)
+}
+
+func formatNodeType(cpuCores, memoryGB, diskGB int) string {
+	return fmt.Sprintf("c%dc-m%dg-d%dg", cpuCores, memoryGB, diskGB)
+}
+
+// newXelonNodeFromDevice returns node resolved via Xelon device, which is not
+// part of Kubernetes cluster in Xelon.
+func newXelonNodeFromDevice(device *xelonDevice) *xelonNode {
+	xn := &xelonNode{
+		localVMID: device.LocalVMID,
+		name:      device.Name,
+		cpuCores:  device.CPUCores,
+		memoryGB:  device.RAM,
+		diskGB:    device.DiskSize,
+		device:    device,
+	}
+	if device.CPUCores > 0 {
+		xn.nodeType = formatNodeType(device.CPUCores, device.RAM, device.DiskSize)
+	}
+	return xn
+}
+
+// buildNodeLabels returns Xelon specific labels for the node, e.g. node pool
+// it belongs to or whether it is a control plane node.
+func buildNodeLabels(xn *xelonNode) map[string]string {
+	labels := make(map[string]string)
+	if xn.controlPlane {
+		labels[nodeLabelControlPlane] = "true"
+	}
+	if xn.nodePoolID != "" {
+		labels[nodeLabelNodePoolID] = sanitizeLabelValue(xn.nodePoolID)
+	}
+	if xn.nodePoolName != "" {
+		labels[nodeLabelNodePoolName] = sanitizeLabelValue(xn.nodePoolName)
+	}
+	if xn.cpuCores > 0 {
+		labels[nodeLabelCPUCores] = strconv.Itoa(xn.cpuCores)
+	}
+	if xn.memoryGB > 0 {
+		labels[nodeLabelMemoryGB] = strconv.Itoa(xn.memoryGB)
+	}
+	if xn.diskGB > 0 {
+		labels[nodeLabelDiskGB] = strconv.Itoa(xn.diskGB)
+	}
+	return labels
+}
+
+// isNodeInitialized returns false while the node is initialized by the cloud
+// node controller, i.e. it still has the uninitialized taint.
+func isNodeInitialized(node *v1.Node) bool {
+	return !slices.ContainsFunc(node.Spec.Taints, func(taint v1.Taint) bool {
+		return taint.Key == cloudproviderapi.TaintExternalCloudProvider
+	})
+}
+
+func isNodeIPAddress(address v1.NodeAddress) bool {
+	return address.Type == v1.NodeInternalIP || address.Type == v1.NodeExternalIP
 }
 
 func parseProviderID(providerID string) (string, error) {
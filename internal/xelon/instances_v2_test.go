@@ -3,12 +3,19 @@ package xelon
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	cloudprovider "k8s.io/cloud-provider"
+	cloudproviderapi "k8s.io/cloud-provider/api"
 
 	"github.com/Xelon-AG/xelon-sdk-go/xelon"
 )
@@ -29,6 +36,8 @@ func TestInstances_refreshNodes(t *testing.T) {
 	})
 	mux.HandleFunc("GET /kubernetes/cluster-id/pools", func(w http.ResponseWriter, _ *http.Request) {
 		nodePools := []xelon.KubernetesClusterNodePool{{
+			ID:       "pool-id",
+			Name:     "workers",
 			CPUCores: 4,
 			DiskSize: 100,
 			RAM:      8,
@@ -45,18 +54,414 @@ func TestInstances_refreshNodes(t *testing.T) {
 
 	xelonClient := xelon.NewClient("token", xelon.WithBaseURL(server.URL+"/"))
 	i := &instances{
-		client:    &clients{xelon: xelonClient},
+		client:    newClients(xelonClient),
 		clusterID: "cluster-id",
 		ttl:       15 * time.Second,
 	}
 
 	err := i.refreshNodes(context.Background())
 
+	controlPlaneNode := xelonNode{
+		localVMID:    "control-plane-vm-id",
+		name:         "control-plane-1",
+		nodeType:     "c2c-m4g-d50g",
+		controlPlane: true,
+		cpuCores:     2,
+		memoryGB:     4,
+		diskGB:       50,
+	}
+	workerNode := xelonNode{
+		localVMID:    "worker-vm-id",
+		name:         "worker-1",
+		nodeType:     "c4c-m8g-d100g",
+		nodePoolID:   "pool-id",
+		nodePoolName: "workers",
+		cpuCores:     4,
+		memoryGB:     8,
+		diskGB:       100,
+	}
 	assert.NoError(t, err)
-	assert.Equal(t, []xelonNode{
-		{localVMID: "control-plane-vm-id", name: "control-plane-1", nodeType: "c2c-m4g-d50g"},
-		{localVMID: "worker-vm-id", name: "worker-1", nodeType: "c4c-m8g-d100g"},
-	}, i.nodes)
+	assert.Equal(t, map[string]xelonNode{"control-plane-vm-id": controlPlaneNode, "worker-vm-id": workerNode}, i.nodesByLocalVMID)
+	assert.Equal(t, map[string]xelonNode{"control-plane-1": controlPlaneNode, "worker-1": workerNode}, i.nodesByName)
+	assert.False(t, i.lastUpdate.IsZero())
+}
+
+func TestInstances_lookupXelonNode(t *testing.T) {
+	type testCase struct {
+		node             *v1.Node
+		expectedNode     *xelonNode
+		expectedErr      error
+		expectedRequests int
+	}
+	tests := map[string]testCase{
+		"cached by name": {
+			node:             &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "control-plane-1"}},
+			expectedNode:     &xelonNode{localVMID: "control-plane-vm-id", name: "control-plane-1"},
+			expectedRequests: 0,
+		},
+		"cached by provider id": {
+			node:             &v1.Node{Spec: v1.NodeSpec{ProviderID: "xelon://control-plane-vm-id"}},
+			expectedNode:     &xelonNode{localVMID: "control-plane-vm-id", name: "control-plane-1"},
+			expectedRequests: 0,
+		},
+		"newly joined node": {
+			node:             &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-1"}},
+			expectedNode:     &xelonNode{localVMID: "worker-vm-id", name: "worker-1", nodeType: "c0c-m0g-d0g"},
+			expectedRequests: 1,
+		},
+		"unknown node": {
+			node:             &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "unknown"}},
+			expectedErr:      cloudprovider.InstanceNotFound,
+			expectedRequests: 1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var requests atomic.Int32
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /kubernetes/cluster-id/control-planes", func(w http.ResponseWriter, _ *http.Request) {
+				requests.Add(1)
+				assert.NoError(t, json.NewEncoder(w).Encode(xelon.KubernetesClusterControlPlane{}))
+			})
+			mux.HandleFunc("GET /kubernetes/cluster-id/pools", func(w http.ResponseWriter, _ *http.Request) {
+				nodePools := []xelon.KubernetesClusterNodePool{{
+					Nodes: []xelon.KubernetesClusterNode{{LocalVMID: "worker-vm-id", Name: "worker-1"}},
+				}}
+				assert.NoError(t, json.NewEncoder(w).Encode(nodePools))
+			})
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			cachedNode := xelonNode{localVMID: "control-plane-vm-id", name: "control-plane-1"}
+			xelonClient := xelon.NewClient("token", xelon.WithBaseURL(server.URL+"/"))
+			i := &instances{
+				client:           newClients(xelonClient),
+				clusterID:        "cluster-id",
+				ttl:              15 * time.Second,
+				maxStaleness:     10 * time.Minute,
+				nodesByLocalVMID: map[string]xelonNode{cachedNode.localVMID: cachedNode},
+				nodesByName:      map[string]xelonNode{cachedNode.name: cachedNode},
+				lastUpdate:       time.Now(),
+			}
+
+			actual, err := i.lookupXelonNode(context.Background(), test.node)
+
+			assert.ErrorIs(t, err, test.expectedErr)
+			if actual != nil {
+				// fetched device is asserted by InstanceMetadata tests
+				actual.device = nil
+			}
+			assert.Equal(t, test.expectedNode, actual)
+			assert.Equal(t, test.expectedRequests, int(requests.Load()))
+		})
+	}
+}
+
+func TestInstances_lookupXelonNode_apiError(t *testing.T) {
+	type testCase struct {
+		node         *v1.Node
+		lastUpdate   time.Time
+		expectedNode *xelonNode
+		expectedErr  bool
+	}
+	tests := map[string]testCase{
+		"serve stale node": {
+			node:         &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "control-plane-1"}},
+			lastUpdate:   time.Now().Add(-5 * time.Minute),
+			expectedNode: &xelonNode{localVMID: "control-plane-vm-id", name: "control-plane-1"},
+		},
+		"stale node older than max staleness": {
+			node:        &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "control-plane-1"}},
+			lastUpdate:  time.Now().Add(-15 * time.Minute),
+			expectedErr: true,
+		},
+		"node is not cached": {
+			node:        &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-1"}},
+			lastUpdate:  time.Now(),
+			expectedErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+			defer server.Close()
+
+			cachedNode := xelonNode{localVMID: "control-plane-vm-id", name: "control-plane-1"}
+			xelonClient := xelon.NewClient("token", xelon.WithBaseURL(server.URL+"/"))
+			i := &instances{
+				client:           newClients(xelonClient),
+				clusterID:        "cluster-id",
+				ttl:              15 * time.Second,
+				maxStaleness:     10 * time.Minute,
+				nodesByLocalVMID: map[string]xelonNode{cachedNode.localVMID: cachedNode},
+				nodesByName:      map[string]xelonNode{cachedNode.name: cachedNode},
+				lastUpdate:       test.lastUpdate,
+			}
+
+			actual, err := i.lookupXelonNode(context.Background(), test.node)
+
+			if test.expectedErr {
+				assert.Error(t, err)
+				assert.NotErrorIs(t, err, cloudprovider.InstanceNotFound)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.expectedNode, actual)
+		})
+	}
+}
+
+func TestInstances_InstanceExists(t *testing.T) {
+	type testCase struct {
+		node             *v1.Node
+		deviceStatusCode int
+		expected         bool
+		expectedErr      bool
+		expectedEvent    string
+		expectedRequests int
+	}
+	tests := map[string]testCase{
+		"listed node": {
+			node:             &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-1"}},
+			expected:         true,
+			expectedRequests: 1,
+		},
+		"vm not found": {
+			node:             &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-2"}, Spec: v1.NodeSpec{ProviderID: "xelon://worker-2-vm-id"}},
+			deviceStatusCode: http.StatusNotFound,
+			expected:         false,
+			expectedEvent:    "Normal XelonNodeDeleted Xelon VM of node worker-2 not found after consecutive refreshes and direct lookup, node is considered deleted",
+			expectedRequests: 2,
+		},
+		"vm exists but is not listed": {
+			node:             &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-2"}, Spec: v1.NodeSpec{ProviderID: "xelon://worker-2-vm-id"}},
+			deviceStatusCode: http.StatusOK,
+			expected:         true,
+			expectedRequests: 2,
+		},
+		"vm recreated under the same name": {
+			node:             &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-1"}, Spec: v1.NodeSpec{ProviderID: "xelon://old-worker-vm-id"}},
+			deviceStatusCode: http.StatusNotFound,
+			expected:         false,
+			expectedEvent:    "Warning XelonNodeRecreated Xelon VM old-worker-vm-id of node worker-1 was replaced by VM worker-vm-id with the same name, node is considered deleted and registers again",
+			expectedRequests: 2,
+		},
+		"vm lookup failed": {
+			node:             &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-2"}, Spec: v1.NodeSpec{ProviderID: "xelon://worker-2-vm-id"}},
+			deviceStatusCode: http.StatusInternalServerError,
+			expectedErr:      true,
+			expectedRequests: 2,
+		},
+		"node without provider id": {
+			node:             &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-2"}},
+			expected:         false,
+			expectedEvent:    "Normal XelonNodeDeleted Xelon VM of node worker-2 not found after consecutive refreshes and direct lookup, node is considered deleted",
+			expectedRequests: 2,
+		},
+		"vm without provider id exists but is not listed": {
+			node:             &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-3"}},
+			expected:         true,
+			expectedRequests: 2,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var requests atomic.Int32
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /kubernetes/cluster-id/control-planes", func(w http.ResponseWriter, _ *http.Request) {
+				requests.Add(1)
+				assert.NoError(t, json.NewEncoder(w).Encode(xelon.KubernetesClusterControlPlane{}))
+			})
+			mux.HandleFunc("GET /kubernetes/cluster-id/pools", func(w http.ResponseWriter, _ *http.Request) {
+				nodePools := []xelon.KubernetesClusterNodePool{{
+					Nodes: []xelon.KubernetesClusterNode{{LocalVMID: "worker-vm-id", Name: "worker-1"}},
+				}}
+				assert.NoError(t, json.NewEncoder(w).Encode(nodePools))
+			})
+			mux.HandleFunc("GET /tenant-id/devices/{id}", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(test.deviceStatusCode)
+				_, _ = fmt.Fprintf(w, `{"device":{"localvmid":%q}}`, r.PathValue("id"))
+			})
+			mux.HandleFunc("GET /tenant-id/devices", func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Query().Get("search") == "worker-3" {
+					_, _ = w.Write([]byte(`{"data":[{"localvmid":"worker-3-vm-id","name":"worker-3"}]}`))
+					return
+				}
+				_, _ = w.Write([]byte(`{"data":[]}`))
+			})
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			recorder := record.NewFakeRecorder(10)
+			c := newClients(xelon.NewClient("token", xelon.WithBaseURL(server.URL+"/")))
+			c.recorder = recorder
+			i := &instances{
+				client:       c,
+				tenant:       &tenantResolver{id: "tenant-id", resolved: true},
+				clusterID:    "cluster-id",
+				ttl:          15 * time.Second,
+				maxStaleness: 10 * time.Minute,
+			}
+
+			exists, err := i.InstanceExists(context.Background(), test.node)
+
+			if test.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.expected, exists)
+			assert.Equal(t, test.expectedRequests, int(requests.Load()))
+			if test.expectedEvent != "" {
+				assert.Equal(t, test.expectedEvent, <-recorder.Events)
+			}
+			assert.Empty(t, recorder.Events)
+		})
+	}
+}
+
+func TestInstances_lookupXelonNode_deviceFallback(t *testing.T) {
+	type testCase struct {
+		clusterID    string
+		node         *v1.Node
+		expectedNode *xelonNode
+		expectedErr  error
+	}
+	tests := map[string]testCase{
+		"cluster node": {
+			clusterID:    "cluster-id",
+			node:         &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-1"}},
+			expectedNode: &xelonNode{localVMID: "worker-vm-id", name: "worker-1", nodeType: "c0c-m0g-d0g"},
+		},
+		"device by provider id": {
+			clusterID:    "cluster-id",
+			node:         &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "gpu-1"}, Spec: v1.NodeSpec{ProviderID: "xelon://gpu-vm-id"}},
+			expectedNode: &xelonNode{localVMID: "gpu-vm-id", name: "gpu-1", nodeType: "c8c-m32g-d200g", cpuCores: 8, memoryGB: 32, diskGB: 200},
+		},
+		"device by hostname": {
+			clusterID:    "cluster-id",
+			node:         &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "gpu-1.example.com"}},
+			expectedNode: &xelonNode{localVMID: "gpu-vm-id", name: "gpu-1"},
+		},
+		"unknown device by provider id": {
+			clusterID:   "cluster-id",
+			node:        &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "unknown"}, Spec: v1.NodeSpec{ProviderID: "xelon://unknown-vm-id"}},
+			expectedErr: cloudprovider.InstanceNotFound,
+		},
+		"unknown device by hostname": {
+			clusterID:   "cluster-id",
+			node:        &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "unknown"}},
+			expectedErr: cloudprovider.InstanceNotFound,
+		},
+		"without kubernetes cluster id": {
+			clusterID:    "",
+			node:         &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "gpu-1"}},
+			expectedNode: &xelonNode{localVMID: "gpu-vm-id", name: "gpu-1"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /kubernetes/cluster-id/control-planes", func(w http.ResponseWriter, _ *http.Request) {
+				assert.NoError(t, json.NewEncoder(w).Encode(xelon.KubernetesClusterControlPlane{}))
+			})
+			mux.HandleFunc("GET /kubernetes/cluster-id/pools", func(w http.ResponseWriter, _ *http.Request) {
+				nodePools := []xelon.KubernetesClusterNodePool{{
+					Nodes: []xelon.KubernetesClusterNode{{LocalVMID: "worker-vm-id", Name: "worker-1"}},
+				}}
+				assert.NoError(t, json.NewEncoder(w).Encode(nodePools))
+			})
+			mux.HandleFunc("GET /tenant-id/devices/gpu-vm-id", func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte(`{"device":{"localvmid":"gpu-vm-id","name":"gpu-1","hostname":"gpu-1.example.com","cpuCores":8,"ram":32,"diskSize":200}}`))
+			})
+			mux.HandleFunc("GET /tenant-id/devices/unknown-vm-id", func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			})
+			mux.HandleFunc("GET /tenant-id/devices", func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Query().Get("search") == "unknown" {
+					_, _ = w.Write([]byte(`{"data":[{"localvmid":"unknown-2-vm-id","name":"unknown-2"}]}`))
+					return
+				}
+				_, _ = w.Write([]byte(`{"data":[{"localvmid":"gpu-vm-id","name":"gpu-1","hostname":"gpu-1.example.com"}]}`))
+			})
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			xelonClient := xelon.NewClient("token", xelon.WithBaseURL(server.URL+"/"))
+			i := newInstances(newClients(xelonClient), &tenantResolver{id: "tenant-id", resolved: true}, "cloud-id", test.clusterID, instancesConfig{
+				CacheTTL:       metav1.Duration{Duration: 15 * time.Second},
+				MaxStaleness:   metav1.Duration{Duration: 10 * time.Minute},
+				DeviceFallback: true,
+			})
+
+			actual, err := i.lookupXelonNode(context.Background(), test.node)
+
+			assert.ErrorIs(t, err, test.expectedErr)
+			if actual != nil {
+				// fetched device is asserted by InstanceMetadata tests
+				actual.device = nil
+			}
+			assert.Equal(t, test.expectedNode, actual)
+		})
+	}
+}
+
+func TestInstances_lookupXelonDevice_cache(t *testing.T) {
+	var requests atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /tenant-id/devices/gpu-vm-id", func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		_, _ = w.Write([]byte(`{"device":{"localvmid":"gpu-vm-id","name":"gpu-1"}}`))
+	})
+	mux.HandleFunc("GET /tenant-id/devices", func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		_, _ = w.Write([]byte(`{"data":[]}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	xelonClient := xelon.NewClient("token", xelon.WithBaseURL(server.URL+"/"))
+	i := &instances{
+		client:       newClients(xelonClient),
+		tenant:       &tenantResolver{id: "tenant-id", resolved: true},
+		ttl:          15 * time.Second,
+		maxStaleness: 10 * time.Minute,
+	}
+	found := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "gpu-1"}, Spec: v1.NodeSpec{ProviderID: "xelon://gpu-vm-id"}}
+	missing := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "unknown"}}
+
+	for n := range 2 {
+		actual, err := i.lookupXelonDevice(context.Background(), found)
+		assert.NoError(t, err)
+		// only a just fetched device is returned, cached lookups fetch it again when needed
+		assert.Equal(t, n == 0, actual.device != nil)
+		actual.device = nil
+		assert.Equal(t, &xelonNode{localVMID: "gpu-vm-id", name: "gpu-1"}, actual)
+
+		_, err = i.lookupXelonDevice(context.Background(), missing)
+		assert.ErrorIs(t, err, cloudprovider.InstanceNotFound)
+	}
+	assert.Equal(t, int32(2), requests.Load())
+
+	// missing devices are looked up again after ttl, found ones are still cached
+	i.mu.Lock()
+	for key, lookup := range i.deviceLookups {
+		lookup.fetchedAt = lookup.fetchedAt.Add(-time.Minute)
+		i.deviceLookups[key] = lookup
+	}
+	i.mu.Unlock()
+
+	_, err := i.lookupXelonDevice(context.Background(), found)
+	assert.NoError(t, err)
+	_, err = i.lookupXelonDevice(context.Background(), missing)
+	assert.ErrorIs(t, err, cloudprovider.InstanceNotFound)
+	assert.Equal(t, int32(3), requests.Load())
 }
 
 func TestInstances_getNodeTypeFromControlPlaneNode(t *testing.T) {
@@ -114,3 +519,315 @@ func TestInstances_getNodeTypeFromNodePool(t *testing.T) {
 		})
 	}
 }
+
+func TestInstances_InstanceShutdown(t *testing.T) {
+	type testCase struct {
+		deviceResponse string
+		statusCode     int
+		expected       bool
+	}
+	tests := map[string]testCase{
+		"powered on": {
+			deviceResponse: `{"device":{"localvmid":"worker-vm-id","powerstate":true}}`,
+			statusCode:     http.StatusOK,
+			expected:       false,
+		},
+		"powered off": {
+			deviceResponse: `{"device":{"localvmid":"worker-vm-id","powerstate":false}}`,
+			statusCode:     http.StatusOK,
+			expected:       true,
+		},
+		"stopped": {
+			deviceResponse: `{"device":{"localvmid":"worker-vm-id","state":"stopped","powerstate":false}}`,
+			statusCode:     http.StatusOK,
+			expected:       true,
+		},
+		"suspended": {
+			deviceResponse: `{"device":{"localvmid":"worker-vm-id","state":"suspended","powerstate":true}}`,
+			statusCode:     http.StatusOK,
+			expected:       true,
+		},
+		"running": {
+			deviceResponse: `{"device":{"localvmid":"worker-vm-id","state":"running"}}`,
+			statusCode:     http.StatusOK,
+			expected:       false,
+		},
+		"missing power state": {
+			deviceResponse: `{"device":{"localvmid":"worker-vm-id"}}`,
+			statusCode:     http.StatusOK,
+			expected:       false,
+		},
+		"null power state": {
+			deviceResponse: `{"device":{"localvmid":"worker-vm-id","powerstate":null}}`,
+			statusCode:     http.StatusOK,
+			expected:       false,
+		},
+		"unknown state": {
+			deviceResponse: `{"device":{"localvmid":"worker-vm-id","state":"migrating"}}`,
+			statusCode:     http.StatusOK,
+			expected:       false,
+		},
+		"not found": {
+			statusCode: http.StatusNotFound,
+			expected:   false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /kubernetes/cluster-id/control-planes", func(w http.ResponseWriter, _ *http.Request) {
+				assert.NoError(t, json.NewEncoder(w).Encode(xelon.KubernetesClusterControlPlane{}))
+			})
+			mux.HandleFunc("GET /kubernetes/cluster-id/pools", func(w http.ResponseWriter, _ *http.Request) {
+				nodePools := []xelon.KubernetesClusterNodePool{{
+					Nodes: []xelon.KubernetesClusterNode{{LocalVMID: "worker-vm-id", Name: "worker-1"}},
+				}}
+				assert.NoError(t, json.NewEncoder(w).Encode(nodePools))
+			})
+			mux.HandleFunc("GET /tenant-id/devices/worker-vm-id", func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(test.statusCode)
+				_, _ = w.Write([]byte(test.deviceResponse))
+			})
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			xelonClient := xelon.NewClient("token", xelon.WithBaseURL(server.URL+"/"))
+			i := &instances{
+				client:       newClients(xelonClient),
+				tenant:       &tenantResolver{id: "tenant-id", resolved: true},
+				clusterID:    "cluster-id",
+				ttl:          15 * time.Second,
+				maxStaleness: 10 * time.Minute,
+			}
+			node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-1"}}
+
+			shutdown, err := i.InstanceShutdown(context.Background(), node)
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, shutdown)
+		})
+	}
+}
+
+func TestInstances_InstanceMetadata_instanceType(t *testing.T) {
+	type testCase struct {
+		deviceResponse string
+		expected       string
+	}
+	tests := map[string]testCase{
+		"product name": {
+			deviceResponse: `{"device":{"localvmid":"worker-vm-id","product":{"id":"1","name":"Standard L"}}}`,
+			expected:       "standard-l",
+		},
+		"without product": {
+			deviceResponse: `{"device":{"localvmid":"worker-vm-id"}}`,
+			expected:       "c4c-m8g-d100g",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte(test.deviceResponse))
+			}))
+			defer server.Close()
+
+			workerNode := xelonNode{localVMID: "worker-vm-id", name: "worker-1", nodeType: "c4c-m8g-d100g", cpuCores: 4, memoryGB: 8, diskGB: 100}
+			xelonClient := xelon.NewClient("token", xelon.WithBaseURL(server.URL+"/"))
+			i := &instances{
+				client:           newClients(xelonClient),
+				tenant:           &tenantResolver{id: "tenant-id", resolved: true},
+				clusterID:        "cluster-id",
+				ttl:              15 * time.Second,
+				maxStaleness:     10 * time.Minute,
+				nodesByLocalVMID: map[string]xelonNode{workerNode.localVMID: workerNode},
+				nodesByName:      map[string]xelonNode{workerNode.name: workerNode},
+				lastUpdate:       time.Now(),
+			}
+			node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-1"}}
+
+			meta, err := i.InstanceMetadata(context.Background(), node)
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, meta.InstanceType)
+			assert.Equal(t, "8", meta.AdditionalLabels[nodeLabelMemoryGB])
+		})
+	}
+}
+
+func TestInstances_InstanceMetadata_deviceError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	workerNode := xelonNode{localVMID: "worker-vm-id", name: "worker-1", nodeType: "c4c-m8g-d100g", nodePoolID: "pool-1", cpuCores: 4}
+	xelonClient := xelon.NewClient("token", xelon.WithBaseURL(server.URL+"/"))
+	i := &instances{
+		client:           newClients(xelonClient),
+		tenant:           &tenantResolver{id: "tenant-id", resolved: true},
+		clusterID:        "cluster-id",
+		ttl:              15 * time.Second,
+		maxStaleness:     10 * time.Minute,
+		nodesByLocalVMID: map[string]xelonNode{workerNode.localVMID: workerNode},
+		nodesByName:      map[string]xelonNode{workerNode.name: workerNode},
+		lastUpdate:       time.Now(),
+	}
+	addresses := []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "10.0.0.10"}}
+	node := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker-1"},
+		Status:     v1.NodeStatus{Addresses: addresses},
+	}
+
+	meta, err := i.InstanceMetadata(context.Background(), node)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &cloudprovider.InstanceMetadata{
+		ProviderID:    "xelon://worker-vm-id",
+		InstanceType:  "c4c-m8g-d100g",
+		NodeAddresses: addresses,
+		AdditionalLabels: map[string]string{
+			nodeLabelNodePoolID: "pool-1",
+			nodeLabelCPUCores:   "4",
+		},
+	}, meta)
+}
+
+func TestBuildNodeLabels(t *testing.T) {
+	type testCase struct {
+		input    *xelonNode
+		expected map[string]string
+	}
+	tests := map[string]testCase{
+		"control plane node": {
+			input: &xelonNode{localVMID: "control-plane-vm-id", controlPlane: true},
+			expected: map[string]string{
+				nodeLabelControlPlane: "true",
+			},
+		},
+		"node pool node": {
+			input: &xelonNode{localVMID: "worker-vm-id", nodePoolID: "pool-id", nodePoolName: "Workers Pool"},
+			expected: map[string]string{
+				nodeLabelNodePoolID:   "pool-id",
+				nodeLabelNodePoolName: "workers-pool",
+			},
+		},
+		"node with capacity": {
+			input: &xelonNode{localVMID: "worker-vm-id", cpuCores: 4, memoryGB: 8, diskGB: 100},
+			expected: map[string]string{
+				nodeLabelCPUCores: "4",
+				nodeLabelMemoryGB: "8",
+				nodeLabelDiskGB:   "100",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			actual := buildNodeLabels(test.input)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestInstances_InstanceMetadata_deviceFallbackFetchesDeviceOnce(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		_, _ = w.Write([]byte(`{"device":{"localvmid":"gpu-vm-id","name":"gpu-1","networks":[{"ipAddress":"10.0.0.20"}],"cloud":{"name":"Zurich"}}}`))
+	}))
+	defer server.Close()
+
+	xelonClient := xelon.NewClient("token", xelon.WithBaseURL(server.URL+"/"))
+	i := newInstances(newClients(xelonClient), &tenantResolver{id: "tenant-id", resolved: true}, "cloud-id", "", instancesConfig{
+		CacheTTL:     metav1.Duration{Duration: 15 * time.Second},
+		MaxStaleness: metav1.Duration{Duration: 10 * time.Minute},
+	})
+	node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "gpu-1"}, Spec: v1.NodeSpec{ProviderID: "xelon://gpu-vm-id"}}
+
+	meta, err := i.InstanceMetadata(context.Background(), node)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "zurich", meta.Region)
+	assert.Contains(t, meta.NodeAddresses, v1.NodeAddress{Type: v1.NodeInternalIP, Address: "10.0.0.20"})
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+func TestInstances_InstanceMetadata_topologyOfUninitializedNode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	workerNode := xelonNode{localVMID: "worker-vm-id", name: "worker-1", nodeType: "c4c-m8g-d100g"}
+	xelonClient := xelon.NewClient("token", xelon.WithBaseURL(server.URL+"/"))
+	i := &instances{
+		client:           newClients(xelonClient),
+		tenant:           &tenantResolver{id: "tenant-id", resolved: true},
+		cloudID:          "cloud-id",
+		clusterID:        "cluster-id",
+		ttl:              15 * time.Second,
+		maxStaleness:     10 * time.Minute,
+		nodesByLocalVMID: map[string]xelonNode{workerNode.localVMID: workerNode},
+		nodesByName:      map[string]xelonNode{workerNode.name: workerNode},
+		lastUpdate:       time.Now(),
+	}
+	node := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker-1"},
+		Spec: v1.NodeSpec{Taints: []v1.Taint{
+			{Key: cloudproviderapi.TaintExternalCloudProvider, Value: "true", Effect: v1.TaintEffectNoSchedule},
+		}},
+	}
+
+	meta, err := i.InstanceMetadata(context.Background(), node)
+
+	// region and zone are only applied while the node is initialized, so
+	// initialization is retried instead of returning empty topology
+	assert.ErrorContains(t, err, "failed to get Xelon device of node worker-1")
+	assert.Nil(t, meta)
+}
+
+func TestInstances_InstanceMetadata_instanceTypeOfUninitializedNode(t *testing.T) {
+	var failing atomic.Bool
+	failing.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte(`{"device":{"localvmid":"worker-vm-id","product":{"id":"1","name":"Standard L"}}}`))
+	}))
+	defer server.Close()
+
+	workerNode := xelonNode{localVMID: "worker-vm-id", name: "worker-1", nodeType: "c4c-m8g-d100g", cpuCores: 4, memoryGB: 8}
+	xelonClient := xelon.NewClient("token", xelon.WithBaseURL(server.URL+"/"))
+	i := &instances{
+		client:           newClients(xelonClient),
+		tenant:           &tenantResolver{id: "tenant-id", resolved: true},
+		clusterID:        "cluster-id",
+		ttl:              15 * time.Second,
+		maxStaleness:     10 * time.Minute,
+		nodesByLocalVMID: map[string]xelonNode{workerNode.localVMID: workerNode},
+		nodesByName:      map[string]xelonNode{workerNode.name: workerNode},
+		lastUpdate:       time.Now(),
+	}
+	node := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker-1"},
+		Spec: v1.NodeSpec{Taints: []v1.Taint{
+			{Key: cloudproviderapi.TaintExternalCloudProvider, Value: "true", Effect: v1.TaintEffectNoSchedule},
+		}},
+	}
+
+	// fallback instance type is never applied, initialization is retried instead
+	meta, err := i.InstanceMetadata(context.Background(), node)
+	assert.Error(t, err)
+	assert.Nil(t, meta)
+
+	failing.Store(false)
+	meta, err = i.InstanceMetadata(context.Background(), node)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "standard-l", meta.InstanceType)
+	assert.Equal(t, map[string]string{nodeLabelCPUCores: "4", nodeLabelMemoryGB: "8"}, meta.AdditionalLabels)
+}
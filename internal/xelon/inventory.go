@@ -0,0 +1,106 @@
+package xelon
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+)
+
+const (
+	inventoryRoleControlPlane = "control-plane"
+	inventoryRoleWorker       = "worker"
+)
+
+// inventory periodically exposes Xelon resources used by the Kubernetes
+// cluster as metrics: nodes from the instances cache and load balancer
+// clusters with their virtual IPs and forwarding rules.
+type inventory struct {
+	clients   *clients
+	instances *instances
+	clusterID string
+	interval  time.Duration
+}
+
+func newInventory(clients *clients, instances *instances, clusterID string, config inventoryConfig) *inventory {
+	return &inventory{
+		clients:   clients,
+		instances: instances,
+		clusterID: clusterID,
+		interval:  config.SyncInterval.Duration,
+	}
+}
+
+func (i *inventory) run(ctx context.Context) {
+	registerMetrics()
+	klog.InfoS("Updating inventory metrics of Xelon resources", "cluster_id", i.clusterID, "interval", i.interval)
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := i.sync(ctx); err != nil {
+			klog.ErrorS(err, "Failed to update inventory metrics of Xelon resources")
+		}
+	}, i.interval)
+}
+
+func (i *inventory) sync(ctx context.Context) error {
+	type nodeKey struct{ role, nodePool string }
+	nodes := make(map[nodeKey]int)
+	for _, node := range i.instances.cachedNodes() {
+		role := inventoryRoleWorker
+		if node.controlPlane {
+			role = inventoryRoleControlPlane
+		}
+		nodes[nodeKey{role: role, nodePool: node.nodePoolName}]++
+	}
+	inventoryNodes.Reset()
+	for key, count := range nodes {
+		inventoryNodes.WithLabelValues(key.role, key.nodePool).Set(float64(count))
+	}
+
+	if i.clusterID == "" {
+		// load balancer clusters are bound to XKS clusters
+		return nil
+	}
+
+	loadBalancerClusters, _, err := i.clients.xelon().LoadBalancerClusters.List(ctx)
+	if err != nil {
+		return err
+	}
+	clustersByStatus := make(map[string]int)
+	virtualIPsByState := make(map[string]int)
+	forwardingRules := 0
+	for _, loadBalancerCluster := range loadBalancerClusters {
+		if loadBalancerCluster.KubernetesClusterID != i.clusterID {
+			continue
+		}
+		clustersByStatus[loadBalancerCluster.Status]++
+		if loadBalancerCluster.Status != xelonLoadBalancerClusterStatusActive {
+			continue
+		}
+
+		virtualIPs, _, err := i.clients.xelon().LoadBalancerClusters.ListVirtualIPs(ctx, loadBalancerCluster.ID)
+		if err != nil {
+			return err
+		}
+		for _, virtualIP := range virtualIPs {
+			virtualIPsByState[virtualIP.State]++
+			rules, _, err := i.clients.xelon().LoadBalancerClusters.ListForwardingRules(ctx, loadBalancerCluster.ID, virtualIP.ID)
+			if err != nil {
+				return err
+			}
+			forwardingRules += len(rules)
+		}
+	}
+
+	inventoryLoadBalancerClusters.Reset()
+	for status, count := range clustersByStatus {
+		inventoryLoadBalancerClusters.WithLabelValues(status).Set(float64(count))
+	}
+	inventoryVirtualIPs.Reset()
+	for state, count := range virtualIPsByState {
+		inventoryVirtualIPs.WithLabelValues(state).Set(float64(count))
+	}
+	inventoryForwardingRules.Set(float64(forwardingRules))
+
+	return nil
+}
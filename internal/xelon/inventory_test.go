@@ -0,0 +1,73 @@
+package xelon
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/testutil"
+
+	"github.com/Xelon-AG/xelon-sdk-go/xelon"
+)
+
+func TestInventory_sync(t *testing.T) {
+	writeJSON := func(w http.ResponseWriter, v any) {
+		assert.NoError(t, json.NewEncoder(w).Encode(v))
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /lb-clusters", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, []xelon.LoadBalancerCluster{
+			{ID: "lb-1", Status: xelonLoadBalancerClusterStatusActive, KubernetesClusterID: "cluster-id"},
+			{ID: "lb-2", Status: xelonLoadBalancerClusterStatusProvisioning, KubernetesClusterID: "cluster-id"},
+			{ID: "lb-3", Status: xelonLoadBalancerClusterStatusActive, KubernetesClusterID: "other-cluster-id"},
+		})
+	})
+	mux.HandleFunc("GET /lb-clusters/lb-1/virtual-ips", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, []xelon.LoadBalancerClusterVirtualIP{{ID: "vip-1", State: "free"}, {ID: "vip-2", State: "reserved"}})
+	})
+	mux.HandleFunc("GET /lb-clusters/lb-1/virtual-ips/vip-1/forwarding-rules", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, []xelon.LoadBalancerClusterForwardingRule{
+			{Frontend: &xelon.LoadBalancerClusterForwardingRuleFrontendConfiguration{ID: "rule-1", Port: 80}},
+			{Frontend: &xelon.LoadBalancerClusterForwardingRuleFrontendConfiguration{ID: "rule-2", Port: 443}},
+		})
+	})
+	mux.HandleFunc("GET /lb-clusters/lb-1/virtual-ips/vip-2/forwarding-rules", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, []xelon.LoadBalancerClusterForwardingRule{})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	i := &instances{}
+	i.storeNodes([]xelonNode{
+		{localVMID: "cp-1", controlPlane: true},
+		{localVMID: "worker-1", nodePoolName: "pool-a"},
+		{localVMID: "worker-2", nodePoolName: "pool-a"},
+		{localVMID: "worker-3", nodePoolName: "pool-b"},
+	})
+	inv := newInventory(newClients(xelon.NewClient("token", xelon.WithBaseURL(server.URL+"/"))), i, "cluster-id", inventoryConfig{})
+
+	registerMetrics()
+	err := inv.sync(context.Background())
+
+	assert.NoError(t, err)
+	assertGaugeValue(t, 1, inventoryNodes.WithLabelValues(inventoryRoleControlPlane, ""))
+	assertGaugeValue(t, 2, inventoryNodes.WithLabelValues(inventoryRoleWorker, "pool-a"))
+	assertGaugeValue(t, 1, inventoryNodes.WithLabelValues(inventoryRoleWorker, "pool-b"))
+	assertGaugeValue(t, 1, inventoryLoadBalancerClusters.WithLabelValues(xelonLoadBalancerClusterStatusActive))
+	assertGaugeValue(t, 1, inventoryLoadBalancerClusters.WithLabelValues(xelonLoadBalancerClusterStatusProvisioning))
+	assertGaugeValue(t, 1, inventoryVirtualIPs.WithLabelValues("free"))
+	assertGaugeValue(t, 1, inventoryVirtualIPs.WithLabelValues("reserved"))
+	assertGaugeValue(t, 2, inventoryForwardingRules)
+}
+
+func assertGaugeValue(t *testing.T, expected float64, gauge metrics.GaugeMetric) {
+	t.Helper()
+
+	actual, err := testutil.GetGaugeMetricValue(gauge)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual)
+}
@@ -6,13 +6,13 @@ import (
 	"fmt"
 	"net/http"
 	"slices"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
 	cloudprovider "k8s.io/cloud-provider"
 	apierrors "k8s.io/cloud-provider/api"
 	"k8s.io/klog/v2"
@@ -23,6 +23,10 @@ import (
 const (
 	xelonLoadBalancerClusterStatusActive           = "Active"
 	xelonLoadBalancerClusterStatusProvisioning     = "Provisioning"
+	xelonLoadBalancerClusterStatusFailed           = "Failed"
+	xelonLoadBalancerClusterStatusError            = "Error"
+	xelonLoadBalancerClusterStatusDeleting         = "Deleting"
+	xelonLoadBalancerClusterStatusDeleted          = "Deleted"
 	xelonLoadBalancerClusterVirtualIPStateReserved = "reserved"
 
 	// serviceAnnotationLoadBalancerClusterID is the annotation used on the service
@@ -37,6 +41,52 @@ const (
 	// to identify frontend forwarding rules for the virtual IP. Comma-separated, read-only.
 	serviceAnnotationLoadBalancerClusterForwardingRuleIDs = "kubernetes.xelon.ch/load-balancer-cluster-forwarding-rule-ids"
 
+	// serviceAnnotationLoadBalancerOwner is the annotation used on the service to bind
+	// other read-only annotations to the service UID, so they are detected if copied
+	// to another service. Read-only.
+	serviceAnnotationLoadBalancerOwner = "kubernetes.xelon.ch/load-balancer-owner"
+
+	// serviceAnnotationLoadBalancerMigrationSourceClusterID is the annotation used on the
+	// service to identify the load balancer cluster the service is migrated from. Read-only.
+	serviceAnnotationLoadBalancerMigrationSourceClusterID = "kubernetes.xelon.ch/load-balancer-migration-source-cluster-id"
+
+	// serviceAnnotationLoadBalancerMigrationSourceVirtualIPID is the annotation used on the
+	// service to identify the virtual IP the service is migrated from. Read-only.
+	serviceAnnotationLoadBalancerMigrationSourceVirtualIPID = "kubernetes.xelon.ch/load-balancer-migration-source-virtual-ip-id"
+
+	// serviceAnnotationLoadBalancerMigrationSourceForwardingRuleIDs is the annotation used on
+	// the service to identify frontend forwarding rules on the virtual IP the service is
+	// migrated from. Comma-separated, read-only.
+	serviceAnnotationLoadBalancerMigrationSourceForwardingRuleIDs = "kubernetes.xelon.ch/load-balancer-migration-source-forwarding-rule-ids"
+
+	// serviceAnnotationLoadBalancerMigrationDrainUntil is the annotation used on the service
+	// to keep forwarding rules on the source virtual IP until the given time (RFC 3339). Read-only.
+	serviceAnnotationLoadBalancerMigrationDrainUntil = "kubernetes.xelon.ch/load-balancer-migration-drain-until"
+
+	// serviceAnnotationLoadBalancerTargetClusterID is the annotation used on the service
+	// to request migration of the service to another load balancer cluster.
+	serviceAnnotationLoadBalancerTargetClusterID = "service.beta.kubernetes.io/xelon-load-balancer-target-cluster-id"
+
+	// serviceAnnotationLoadBalancerTargetVirtualIPID is the annotation used on the service
+	// to request migration of the service to another virtual IP. If the target cluster is
+	// not specified, the virtual IP has to belong to the current load balancer cluster.
+	serviceAnnotationLoadBalancerTargetVirtualIPID = "service.beta.kubernetes.io/xelon-load-balancer-target-virtual-ip-id"
+
+	// serviceAnnotationLoadBalancerMigrationDrainPeriod is the annotation used on the service
+	// to keep forwarding rules on the source virtual IP for the given duration (e.g. "10m")
+	// after the service was migrated, so clients can pick up the new address.
+	serviceAnnotationLoadBalancerMigrationDrainPeriod = "service.beta.kubernetes.io/xelon-load-balancer-migration-drain-period"
+
+	// serviceAnnotationLoadBalancerRetainOnDelete is the annotation used on the service
+	// to keep forwarding rules and virtual IP association when the service is deleted
+	// or changed to another type. Retained rules can be claimed by another service.
+	serviceAnnotationLoadBalancerRetainOnDelete = "service.beta.kubernetes.io/xelon-load-balancer-retain-on-delete"
+
+	// serviceAnnotationLoadBalancerClaim is the annotation used on the service to
+	// re-adopt forwarding rules retained by a service with the given name in the
+	// same namespace.
+	serviceAnnotationLoadBalancerClaim = "service.beta.kubernetes.io/xelon-load-balancer-claim"
+
 	// serviceAnnotationLoadBalancerClusterProxyProtocolVersion is the annotation
 	// used on the service to allow to specify proxy protocol version.
 	//
@@ -48,8 +98,19 @@ const (
 
 var (
 	errLoadBalancerNotFound             = errors.New("load balancer not found")
-	errLoadBalancerProvisioning         = errors.New("load balancer is being provisioned")
+	errLoadBalancerNotActive            = errors.New("load balancer cluster is not active yet")
 	errLoadBalancerNoVirtualIPAvailable = errors.New("load balancer cluster virtual ip is not available")
+	errLoadBalancerForeignAnnotations   = errors.New("load balancer annotations belong to another service")
+
+	// xelonLoadBalancerClusterFinalStatuses are statuses from which load
+	// balancer clusters do not become active by themselves, all other
+	// statuses except "Active" are considered transitional and retried.
+	xelonLoadBalancerClusterFinalStatuses = []string{
+		xelonLoadBalancerClusterStatusFailed,
+		xelonLoadBalancerClusterStatusError,
+		xelonLoadBalancerClusterStatusDeleting,
+		xelonLoadBalancerClusterStatusDeleted,
+	}
 
 	_ cloudprovider.LoadBalancer = &loadBalancers{}
 )
@@ -57,10 +118,18 @@ var (
 type loadBalancers struct {
 	client *clients
 
-	tenantID  string
+	tenant    *tenantResolver
 	cloudID   string
 	clusterID string
 
+	config       loadBalancersConfig
+	provisioning *provisioningBackoff
+	drains       *migrationDrains
+	now          func() time.Time
+
+	// dryRunEvents keeps the last dry-run event message per service
+	dryRunEvents map[types.UID]string
+
 	*sync.RWMutex
 }
 
@@ -77,12 +146,17 @@ type xelonLoadBalancer struct {
 	forwardingRules  []xelon.LoadBalancerClusterForwardingRule
 }
 
-func newLoadBalancers(clients *clients, tenantID, cloudID, clusterID string) cloudprovider.LoadBalancer {
+func newLoadBalancers(clients *clients, tenant *tenantResolver, cloudID, clusterID string, config loadBalancersConfig) cloudprovider.LoadBalancer {
 	return &loadBalancers{
 		client:    clients,
-		tenantID:  tenantID,
+		tenant:    tenant,
 		cloudID:   cloudID,
 		clusterID: clusterID,
+		config:    config,
+
+		provisioning: newProvisioningBackoff(config),
+		drains:       newMigrationDrains(),
+		now:          time.Now,
 
 		RWMutex: &sync.RWMutex{},
 	}
@@ -91,6 +165,17 @@ func newLoadBalancers(clients *clients, tenantID, cloudID, clusterID string) clo
 func (l *loadBalancers) GetLoadBalancer(ctx context.Context, _ string, service *v1.Service) (*v1.LoadBalancerStatus, bool, error) {
 	logger := configureLogger(ctx, "GetLoadBalancer")
 
+	annotations, err := parseServiceAnnotations(service.Annotations)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid service annotations: %w", err)
+	}
+	if err := l.checkOwnership(ctx, service); err != nil {
+		return nil, false, err
+	}
+	if err := l.ensureTenant(ctx); err != nil {
+		return nil, false, err
+	}
+
 	xlb, err := l.retrieveXelonLoadBalancer(ctx, service)
 	if err != nil {
 		if errors.Is(err, errLoadBalancerNotFound) {
@@ -102,7 +187,7 @@ func (l *loadBalancers) GetLoadBalancer(ctx context.Context, _ string, service *
 	logger.WithValues("ip_address", xlb.virtualIPAddress).Info("Load balancer virtual IP address")
 
 	return &v1.LoadBalancerStatus{
-		Ingress: l.buildLoadBalancerStatusIngress(ctx, xlb, service),
+		Ingress: l.buildLoadBalancerStatusIngress(ctx, xlb, service, annotations),
 	}, true, nil
 }
 
@@ -113,6 +198,29 @@ func (l *loadBalancers) GetLoadBalancerName(_ context.Context, _ string, service
 func (l *loadBalancers) EnsureLoadBalancer(ctx context.Context, clusterName string, service *v1.Service, nodes []*v1.Node) (*v1.LoadBalancerStatus, error) {
 	logger := klog.FromContext(ctx).WithValues("method", "EnsureLoadBalancer", "service", getServiceNameWithNamespace(service))
 
+	annotations, err := parseServiceAnnotations(service.Annotations)
+	if err != nil {
+		l.client.recordEventf(service, v1.EventTypeWarning, eventReasonInvalidAnnotations, "Invalid service annotations: %v", err)
+		return nil, fmt.Errorf("invalid service annotations: %w", err)
+	}
+	for _, warning := range annotations.warnings() {
+		logger.Info("Ignoring service annotation", "warning", warning)
+		l.client.recordEventf(service, v1.EventTypeWarning, eventReasonUnknownAnnotations, "Service annotation warning: %s", warning)
+	}
+	if err := l.checkOwnership(ctx, service); err != nil {
+		return nil, err
+	}
+
+	if err := l.ensureTenant(ctx); err != nil {
+		return nil, err
+	}
+	if err := l.claimRetainedLoadBalancer(ctx, service); err != nil {
+		return nil, err
+	}
+	if err := l.startMigration(ctx, service); err != nil {
+		return nil, err
+	}
+
 	xlb, err := l.retrieveXelonLoadBalancer(ctx, service)
 	if err != nil {
 		switch {
@@ -120,8 +228,8 @@ func (l *loadBalancers) EnsureLoadBalancer(ctx context.Context, clusterName stri
 			logger.Info("create case does not supported yet")
 			return nil, err
 
-		case errors.Is(err, errLoadBalancerProvisioning):
-			return nil, apierrors.NewRetryError("load balancer is currently being provisioned", 30*time.Second)
+		case errors.Is(err, errLoadBalancerNotActive):
+			return nil, l.retryNotActive(ctx, service, err)
 
 		default:
 			// unrecoverable error
@@ -129,21 +237,46 @@ func (l *loadBalancers) EnsureLoadBalancer(ctx context.Context, clusterName stri
 		}
 	}
 
+	l.provisioning.reset(service.UID)
+
 	err = l.UpdateLoadBalancer(ctx, clusterName, service, nodes)
 	if err != nil {
 		return nil, err
 	}
 
+	err = l.completeMigration(ctx, service)
+	if err != nil {
+		return nil, err
+	}
+
+	if l.config.DryRun {
+		// keep current status, so the service does not expose not yet configured virtual ip
+		return service.Status.LoadBalancer.DeepCopy(), nil
+	}
+
 	return &v1.LoadBalancerStatus{
-		Ingress: l.buildLoadBalancerStatusIngress(ctx, xlb, service),
+		Ingress: l.buildLoadBalancerStatusIngress(ctx, xlb, service, annotations),
 	}, nil
 }
 
 func (l *loadBalancers) UpdateLoadBalancer(ctx context.Context, _ string, service *v1.Service, _ []*v1.Node) error {
+	if err := l.checkOwnership(ctx, service); err != nil {
+		return err
+	}
+	if err := l.ensureTenant(ctx); err != nil {
+		return err
+	}
+
 	xlb, err := l.retrieveXelonLoadBalancer(ctx, service)
 	if err != nil {
 		return err
 	}
+	if l.config.DryRun {
+		configureLogger(ctx, "UpdateLoadBalancer").Info("Dry-run: load balancer cluster and virtual ip would be used for the service",
+			"service", getServiceNameWithNamespace(service),
+			"cluster_id", xlb.clusterID, "virtual_ip_id", xlb.virtualIPID, "virtual_ip_address", xlb.virtualIPAddress,
+		)
+	}
 
 	err = l.updateLoadBalancer(ctx, xlb, service)
 	if err != nil {
@@ -156,18 +289,57 @@ func (l *loadBalancers) UpdateLoadBalancer(ctx context.Context, _ string, servic
 func (l *loadBalancers) EnsureLoadBalancerDeleted(ctx context.Context, _ string, service *v1.Service) error {
 	logger := configureLogger(ctx, "EnsureLoadBalancerDeleted")
 
+	l.provisioning.reset(service.UID)
+	l.drains.cancel(service.UID)
+
+	// serialized with completing migrations of drained services
+	l.Lock()
+	defer l.Unlock()
+	delete(l.dryRunEvents, service.UID)
+
+	if err := l.checkOwnership(ctx, service); err != nil {
+		if errors.Is(err, errLoadBalancerForeignAnnotations) {
+			// forwarding rules belong to another service, so only the copied
+			// annotations are removed, e.g. so the service can get its own
+			// load balancer once it is switched back to type LoadBalancer
+			logger.Info("Skip deleting forwarding rules of another service", "reason", err.Error())
+			return l.releaseLoadBalancer(ctx, service)
+		}
+		return err
+	}
+	if err := l.ensureTenant(ctx); err != nil {
+		return err
+	}
+	if err := l.deleteMigrationSourceForwardingRules(ctx, service); err != nil {
+		return err
+	}
+
+	annotations := parseControllerAnnotations(service.Annotations)
+	if !annotations.hasLoadBalancer() {
+		logger.Info("No load balancer annotations, no rules delete needed")
+		return l.releaseLoadBalancer(ctx, service)
+	}
+	if annotations.retainOnDelete {
+		logger.Info("Load balancer is retained, no rules delete needed")
+		return l.retainLoadBalancer(ctx, service, annotations)
+	}
+
 	xlb, err := l.retrieveXelonLoadBalancer(ctx, service)
 	if err != nil {
+		if errors.Is(err, errLoadBalancerNotFound) {
+			logger.Info("Load balancer does not exist anymore, no rules delete needed")
+			return l.releaseLoadBalancer(ctx, service)
+		}
 		return err
 	}
 
 	if xlb == nil {
 		logger.Info("xelonLoadBalancer is empty, no rules delete needed")
-		return nil
+		return l.releaseLoadBalancer(ctx, service)
 	}
 	if xlb.forwardingRules == nil {
 		logger.Info("no forwarding rules defined, no rules delete needed")
-		return nil
+		return l.releaseLoadBalancer(ctx, service)
 	}
 
 	var frontendRules []xelon.LoadBalancerClusterForwardingRuleFrontendConfiguration
@@ -177,22 +349,78 @@ func (l *loadBalancers) EnsureLoadBalancerDeleted(ctx context.Context, _ string,
 		}
 	}
 	logger.WithValues("frontend_rules", frontendRules).Info("Following rules will be deleted")
+	if l.config.DryRun {
+		l.client.recordEventf(service, v1.EventTypeNormal, eventReasonLoadBalancerDryRun,
+			"Dry-run: would delete forwarding rules on virtual IP %s: %s", xlb.virtualIPID, formatForwardingRules(xlb.forwardingRules),
+		)
+		return l.releaseLoadBalancer(ctx, service)
+	}
 	for _, frontendRule := range frontendRules {
-		_, err := l.client.xelon.LoadBalancerClusters.DeleteForwardingRule(ctx, xlb.clusterID, xlb.virtualIPID, frontendRule.ID)
+		resp, err := l.client.xelon().LoadBalancerClusters.DeleteForwardingRule(ctx, xlb.clusterID, xlb.virtualIPID, frontendRule.ID)
 		if err != nil {
+			if resp != nil && resp.StatusCode == http.StatusNotFound {
+				logger.Info("Skipped removing not existing forwarding rule", "forwarding_rule_id", frontendRule.ID)
+				continue
+			}
 			return err
 		}
 	}
 
+	return l.releaseLoadBalancer(ctx, service)
+}
+
+// ensureTenant makes sure Xelon tenant is resolved before any load balancer
+// operation, so operations are retried while Xelon API is not reachable.
+func (l *loadBalancers) ensureTenant(ctx context.Context) error {
+	if _, err := l.tenant.tenantID(ctx); err != nil {
+		return apierrors.NewRetryError(err.Error(), l.config.RetryInterval.Duration)
+	}
 	return nil
 }
 
+// retryNotActive returns retry error with exponential backoff per service
+// while load balancer cluster is not active. Once provisioning timeout is
+// exceeded, a warning event is published once and a plain error is returned, so
+// the service controller falls back to its own backoff.
+func (l *loadBalancers) retryNotActive(ctx context.Context, service *v1.Service, err error) error {
+	logger := configureLogger(ctx, "retryNotActive").WithValues(
+		"service", getServiceNameWithNamespace(service),
+	)
+
+	delay, waiting, timedOut := l.provisioning.next(service.UID)
+	if timedOut {
+		if l.provisioning.reportTimeout(service.UID) {
+			l.client.recordEventf(service, v1.EventTypeWarning, eventReasonLoadBalancerProvisioningTimeout,
+				"Gave up waiting for load balancer cluster after %v: %v", waiting.Round(time.Second), err,
+			)
+		}
+		return fmt.Errorf("gave up waiting for load balancer cluster after %v: %w", waiting.Round(time.Second), err)
+	}
+
+	logger.Info("Load balancer cluster is not active yet, retrying", "reason", err.Error(), "retry_after", delay, "waiting", waiting)
+	return apierrors.NewRetryError(err.Error(), delay)
+}
+
 func (l *loadBalancers) retrieveXelonLoadBalancer(ctx context.Context, service *v1.Service) (xlb *xelonLoadBalancer, err error) {
 	logger := configureLogger(ctx, "retrieveXelonLoadBalancer").WithValues(
 		"service", getServiceNameWithNamespace(service),
 	)
-	patcher := newServicePatcher(l.client.k8s, service)
-	defer func() { err = patcher.Patch(ctx) }()
+	// in dry-run, found load balancer is not recorded in service annotations
+	// at all, so the service is never changed
+	if !l.config.DryRun {
+		patcher := newServicePatcher(l.client.k8s, service, false)
+		defer func() {
+			// keep the original error, annotations found so far are patched anyway
+			if patchErr := patcher.Patch(ctx); patchErr != nil {
+				err = errors.Join(err, patchErr)
+			}
+		}()
+	}
+	updateAnnotation := func(annotationName, annotationValue string) {
+		if !l.config.DryRun {
+			updateServiceAnnotation(service, annotationName, annotationValue)
+		}
+	}
 
 	xlb = &xelonLoadBalancer{}
 
@@ -205,12 +433,8 @@ func (l *loadBalancers) retrieveXelonLoadBalancer(ctx context.Context, service *
 			return nil, err
 		}
 
-		if loadBalancerCluster.Status == xelonLoadBalancerClusterStatusProvisioning {
-			// special case for clusters in provisioning state, so EnsureLoadBalancer method can use retry error
-			return nil, errLoadBalancerProvisioning
-		}
-		if loadBalancerCluster.Status != xelonLoadBalancerClusterStatusActive {
-			return nil, fmt.Errorf("load balancer cluster is not active (current status: %v)", loadBalancerCluster.Status)
+		if err := checkLoadBalancerClusterStatus(loadBalancerCluster); err != nil {
+			return nil, err
 		}
 
 		xlb.clusterID = loadBalancerCluster.ID
@@ -222,16 +446,12 @@ func (l *loadBalancers) retrieveXelonLoadBalancer(ctx context.Context, service *
 			return nil, err
 		}
 
-		if loadBalancerCluster.Status == xelonLoadBalancerClusterStatusProvisioning {
-			// special case for clusters in provisioning state, so EnsureLoadBalancer method can use retry error
-			return nil, errLoadBalancerProvisioning
-		}
-		if loadBalancerCluster.Status != xelonLoadBalancerClusterStatusActive {
-			return nil, fmt.Errorf("load balancer cluster is not active (current status: %v)", loadBalancerCluster.Status)
+		if err := checkLoadBalancerClusterStatus(loadBalancerCluster); err != nil {
+			return nil, err
 		}
 
 		xlb.clusterID = loadBalancerCluster.ID
-		updateServiceAnnotation(service, serviceAnnotationLoadBalancerClusterID, loadBalancerCluster.ID)
+		updateAnnotation(serviceAnnotationLoadBalancerClusterID, loadBalancerCluster.ID)
 	}
 
 	// fetch all needed information about virtual IP from the load balancer cluster
@@ -256,7 +476,7 @@ func (l *loadBalancers) retrieveXelonLoadBalancer(ctx context.Context, service *
 		xlb.virtualIPID = virtualIP.ID
 		xlb.virtualIPAddress = virtualIP.IPAddress
 
-		updateServiceAnnotation(service, serviceAnnotationLoadBalancerClusterVirtualIPID, virtualIP.ID)
+		updateAnnotation(serviceAnnotationLoadBalancerClusterVirtualIPID, virtualIP.ID)
 	}
 
 	// fetch all needed information about forwarding rules
@@ -271,13 +491,29 @@ func (l *loadBalancers) retrieveXelonLoadBalancer(ctx context.Context, service *
 		xlb.forwardingRules = forwardingRules
 	}
 
+	updateAnnotation(serviceAnnotationLoadBalancerOwner, string(service.UID))
+
 	return xlb, nil
 }
 
+// checkLoadBalancerClusterStatus returns errLoadBalancerNotActive for clusters
+// in transitional states (e.g. "Provisioning"), so EnsureLoadBalancer method
+// can use retry error, and a plain error for clusters in final states.
+func checkLoadBalancerClusterStatus(loadBalancerCluster *xelon.LoadBalancerCluster) error {
+	switch {
+	case loadBalancerCluster.Status == xelonLoadBalancerClusterStatusActive:
+		return nil
+	case slices.Contains(xelonLoadBalancerClusterFinalStatuses, loadBalancerCluster.Status):
+		return fmt.Errorf("load balancer cluster %s is not active (current status: %v)", loadBalancerCluster.ID, loadBalancerCluster.Status)
+	default:
+		return fmt.Errorf("%w (current status: %v)", errLoadBalancerNotActive, loadBalancerCluster.Status)
+	}
+}
+
 func (l *loadBalancers) fetchXelonLoadBalancerCluster(ctx context.Context, loadBalancerClusterID string) (*xelon.LoadBalancerCluster, error) {
 	logger := configureLogger(ctx, "fetchXelonLoadBalancerCluster")
 
-	loadBalancerCluster, resp, err := l.client.xelon.LoadBalancerClusters.Get(ctx, loadBalancerClusterID)
+	loadBalancerCluster, resp, err := l.client.xelon().LoadBalancerClusters.Get(ctx, loadBalancerClusterID)
 	if err != nil {
 		if resp != nil && resp.StatusCode == http.StatusNotFound {
 			logger.Info("Load balancer cluster does not exist", "id", loadBalancerClusterID)
@@ -296,7 +532,7 @@ func (l *loadBalancers) findOrCreateXelonLoadBalancerCluster(ctx context.Context
 		"service", getServiceNameWithNamespace(service),
 	)
 
-	loadBalancerClusters, _, err := l.client.xelon.LoadBalancerClusters.List(ctx)
+	loadBalancerClusters, _, err := l.client.xelon().LoadBalancerClusters.List(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -337,7 +573,7 @@ func (l *loadBalancers) findOrCreateXelonLoadBalancerCluster(ctx context.Context
 func (l *loadBalancers) fetchXelonLoadBalancerVirtualIP(ctx context.Context, loadbalancerClusterID, virtualIPID string) (*xelon.LoadBalancerClusterVirtualIP, error) {
 	logger := configureLogger(ctx, "fetchXelonLoadBalancerVirtualIP")
 
-	virtualIP, resp, err := l.client.xelon.LoadBalancerClusters.GetVirtualIP(ctx, loadbalancerClusterID, virtualIPID)
+	virtualIP, resp, err := l.client.xelon().LoadBalancerClusters.GetVirtualIP(ctx, loadbalancerClusterID, virtualIPID)
 	if err != nil {
 		if resp != nil && resp.StatusCode == http.StatusNotFound {
 			logger.Info("Load balancer cluster virtual ip does not exist", "id", virtualIPID)
@@ -356,12 +592,12 @@ func (l *loadBalancers) findXelonLoadBalancerClusterVirtualIP(ctx context.Contex
 		"service", getServiceNameWithNamespace(service),
 	)
 
-	virtualIPs, _, err := l.client.xelon.LoadBalancerClusters.ListVirtualIPs(ctx, loadBalancerClusterID)
+	virtualIPs, _, err := l.client.xelon().LoadBalancerClusters.ListVirtualIPs(ctx, loadBalancerClusterID)
 	if err != nil {
 		return nil, err
 	}
 	for _, virtualIP := range virtualIPs {
-		forwardingRules, _, err := l.client.xelon.LoadBalancerClusters.ListForwardingRules(ctx, loadBalancerClusterID, virtualIP.ID)
+		forwardingRules, _, err := l.client.xelon().LoadBalancerClusters.ListForwardingRules(ctx, loadBalancerClusterID, virtualIP.ID)
 		if err != nil {
 			return nil, err
 		}
@@ -380,7 +616,7 @@ func (l *loadBalancers) fetchXelonLoadBalancerForwardingRules(ctx context.Contex
 
 	definedForwardingRuleIDs := strings.Split(forwardingRuleIDs, ",")
 
-	forwardingRules, _, err := l.client.xelon.LoadBalancerClusters.ListForwardingRules(ctx, loadbalancerClusterID, virtualIPID)
+	forwardingRules, _, err := l.client.xelon().LoadBalancerClusters.ListForwardingRules(ctx, loadbalancerClusterID, virtualIPID)
 	if err != nil {
 		return nil, err
 	}
@@ -409,27 +645,25 @@ func (l *loadBalancers) updateLoadBalancer(ctx context.Context, xlb *xelonLoadBa
 	l.Lock()
 	defer l.Unlock()
 
-	patcher := newServicePatcher(l.client.k8s, service)
+	patcher := newServicePatcher(l.client.k8s, service, l.config.DryRun)
 	defer func() { _ = patcher.Patch(ctx) }()
 
+	annotations, err := parseServiceAnnotations(service.Annotations)
+	if err != nil {
+		return fmt.Errorf("invalid service annotations: %w", err)
+	}
+
 	// check proxy_protocol annotation
-	protocolVersion := 0
-	if protocolVersionAsString, ok := service.Annotations[serviceAnnotationLoadBalancerClusterProxyProtocolVersion]; ok && protocolVersionAsString != "" {
-		parsedProtocolVersion, err := strconv.Atoi(protocolVersionAsString)
-		if err != nil {
-			return fmt.Errorf("could not convert proxy protocol version (%v) to integer", protocolVersionAsString)
-		}
-		protocolVersion = parsedProtocolVersion
+	protocolVersion := l.config.ProxyProtocolVersion
+	if annotations.proxyProtocolVersion != nil {
+		protocolVersion = *annotations.proxyProtocolVersion
 		logger.Info("Proxy protocol annotation is defined and will be used for backend forwarding rules", "proxy_protocol", protocolVersion)
 	}
 
 	// get current state
 	var currentForwardingRules []xelon.LoadBalancerClusterForwardingRule
-	var currentForwardingRuleIDs []string
-	if forwardingRuleIDs, ok := service.Annotations[serviceAnnotationLoadBalancerClusterForwardingRuleIDs]; ok && forwardingRuleIDs != "" {
-		currentForwardingRuleIDs = strings.Split(forwardingRuleIDs, ",")
-	}
-	existingForwardingRules, _, err := l.client.xelon.LoadBalancerClusters.ListForwardingRules(ctx, xlb.clusterID, xlb.virtualIPID)
+	currentForwardingRuleIDs := annotations.forwardingRuleIDs
+	existingForwardingRules, _, err := l.client.xelon().LoadBalancerClusters.ListForwardingRules(ctx, xlb.clusterID, xlb.virtualIPID)
 	if err != nil {
 		return err
 	}
@@ -460,10 +694,16 @@ func (l *loadBalancers) updateLoadBalancer(ctx context.Context, xlb *xelonLoadBa
 		"rules_to_delete", reconcileDiff.rulesToDelete,
 	)
 
+	if l.config.DryRun {
+		logger.Info("Dry-run: skip applying forwarding rules", "diff", reconcileDiff.String())
+		l.recordDryRunEvent(service, annotations, xlb, reconcileDiff)
+		return nil
+	}
+
 	var frontendRuleIDs []string
 	if len(reconcileDiff.rulesToCreate) > 0 {
 		logger.Info("Creating new forwarding rules", "payload", reconcileDiff.rulesToCreate)
-		rules, _, err := l.client.xelon.LoadBalancerClusters.CreateForwardingRules(ctx, xlb.clusterID, xlb.virtualIPID, reconcileDiff.rulesToCreate)
+		rules, _, err := l.client.xelon().LoadBalancerClusters.CreateForwardingRules(ctx, xlb.clusterID, xlb.virtualIPID, reconcileDiff.rulesToCreate)
 		if err != nil {
 			return err
 		}
@@ -473,6 +713,11 @@ func (l *loadBalancers) updateLoadBalancer(ctx context.Context, xlb *xelonLoadBa
 			}
 			frontendRuleIDs = append(frontendRuleIDs, rule.Frontend.ID)
 		}
+		// xelon-lb-gc only deletes recorded rules, so a rule failed to be
+		// recorded is never garbage collected but still annotated below
+		if err := recordCreatedForwardingRules(ctx, l.client, xlb.clusterID, xlb.virtualIPID, frontendRuleIDs); err != nil {
+			logger.Error(err, "Failed to record created forwarding rules")
+		}
 	}
 
 	if len(reconcileDiff.rulesToUpdate) > 0 {
@@ -482,7 +727,7 @@ func (l *loadBalancers) updateLoadBalancer(ctx context.Context, xlb *xelonLoadBa
 				ProxyProtocol: ruleToUpdate.Backend.ProxyProtocol,
 			}
 			logger.Info("Updating existing forwarding backend rule", "payload", updateRequest)
-			_, _, err := l.client.xelon.LoadBalancerClusters.UpdateForwardingRule(ctx, xlb.clusterID, xlb.virtualIPID, ruleToUpdate.Backend.ID, updateRequest)
+			_, _, err := l.client.xelon().LoadBalancerClusters.UpdateForwardingRule(ctx, xlb.clusterID, xlb.virtualIPID, ruleToUpdate.Backend.ID, updateRequest)
 			if err != nil {
 				return err
 			}
@@ -495,7 +740,7 @@ func (l *loadBalancers) updateLoadBalancer(ctx context.Context, xlb *xelonLoadBa
 			if ruleToDelete.Frontend == nil {
 				continue
 			}
-			resp, err := l.client.xelon.LoadBalancerClusters.DeleteForwardingRule(ctx, xlb.clusterID, xlb.virtualIPID, ruleToDelete.Frontend.ID)
+			resp, err := l.client.xelon().LoadBalancerClusters.DeleteForwardingRule(ctx, xlb.clusterID, xlb.virtualIPID, ruleToDelete.Frontend.ID)
 			if err != nil {
 				if resp != nil && resp.StatusCode == http.StatusNotFound {
 					logger.Info("Skipped removing not existing forwarding rule", "forwarding_rule_id", ruleToDelete.Frontend.ID)
@@ -525,22 +770,40 @@ func (l *loadBalancers) updateLoadBalancer(ctx context.Context, xlb *xelonLoadBa
 	return nil
 }
 
-func (l *loadBalancers) buildLoadBalancerStatusIngress(ctx context.Context, xlb *xelonLoadBalancer, service *v1.Service) []v1.LoadBalancerIngress {
+// recordDryRunEvent records planned changes of the load balancer as a single
+// event, if forwarding rules are not up to date or another virtual IP would be
+// used. Services are synced repeatedly without being changed in dry-run, so
+// the same plan is recorded only once. Must be called with l locked.
+func (l *loadBalancers) recordDryRunEvent(service *v1.Service, annotations *serviceAnnotations, xlb *xelonLoadBalancer, diff ReconcileDiff) {
+	virtualIPChanged := annotations.loadBalancerClusterID != xlb.clusterID || annotations.virtualIPID != xlb.virtualIPID
+	if diff.isEmpty() && !virtualIPChanged {
+		delete(l.dryRunEvents, service.UID)
+		return
+	}
+
+	message := fmt.Sprintf("Dry-run: would use load balancer cluster %s and virtual IP %s (%s) and apply forwarding rules: %s",
+		xlb.clusterID, xlb.virtualIPID, xlb.virtualIPAddress, diff.String())
+	if l.dryRunEvents[service.UID] == message {
+		return
+	}
+	if l.dryRunEvents == nil {
+		l.dryRunEvents = make(map[types.UID]string)
+	}
+	l.dryRunEvents[service.UID] = message
+	l.client.recordEventf(service, v1.EventTypeNormal, eventReasonLoadBalancerDryRun, "%s", message)
+}
+
+func (l *loadBalancers) buildLoadBalancerStatusIngress(ctx context.Context, xlb *xelonLoadBalancer, service *v1.Service, annotations *serviceAnnotations) []v1.LoadBalancerIngress {
 	logger := configureLogger(ctx, "buildLoadBalancerStatusIngress").WithValues(
 		"service", getServiceNameWithNamespace(service),
 	)
 
 	ipMode := v1.LoadBalancerIPModeVIP
 
-	protocolVersion := 0
-	if protocolVersionAsString, ok := service.Annotations[serviceAnnotationLoadBalancerClusterProxyProtocolVersion]; ok && protocolVersionAsString != "" {
-		parsedProtocolVersion, err := strconv.Atoi(protocolVersionAsString)
-		if err != nil {
-			logger.Info("Could not convert proxy protocol version, fallback to 0")
-		} else {
-			protocolVersion = parsedProtocolVersion
-			logger.Info("Proxy protocol annotation is defined and will be used for load balancer ingress", "proxy_protocol", protocolVersion)
-		}
+	protocolVersion := l.config.ProxyProtocolVersion
+	if annotations.proxyProtocolVersion != nil {
+		protocolVersion = *annotations.proxyProtocolVersion
+		logger.Info("Proxy protocol annotation is defined and will be used for load balancer ingress", "proxy_protocol", protocolVersion)
 	}
 	if protocolVersion > 0 {
 		ipMode = v1.LoadBalancerIPModeProxy
@@ -0,0 +1,201 @@
+package xelon
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+)
+
+// loadBalancerGC deletes forwarding rules on load balancer clusters of the
+// Kubernetes cluster, which are not referenced by any service anymore, e.g.
+// because the service was deleted while the cloud controller manager was down.
+// Only rules recorded as created by the cloud controller manager are deleted,
+// and only if they are found orphaned in two consecutive sweeps, so rules
+// created just before their service was patched are never deleted. Rules
+// created by earlier versions are recorded once they are found in annotations
+// of a service, rules of services deleted before are never recorded.
+type loadBalancerGC struct {
+	clients   *clients
+	clusterID string
+	interval  time.Duration
+	dryRun    bool
+
+	// orphans keeps keys of forwarding rules found orphaned in the previous sweep.
+	orphans sets.Set[string]
+}
+
+func newLoadBalancerGC(clients *clients, clusterID string, config loadBalancersConfig) *loadBalancerGC {
+	return &loadBalancerGC{
+		clients:   clients,
+		clusterID: clusterID,
+		interval:  config.GCInterval.Duration,
+		dryRun:    config.DryRun,
+		orphans:   sets.New[string](),
+	}
+}
+
+func (g *loadBalancerGC) run(ctx context.Context) {
+	registerMetrics()
+	klog.InfoS("Sweeping orphaned forwarding rules of Xelon load balancer clusters", "cluster_id", g.clusterID, "interval", g.interval, "dry_run", g.dryRun)
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := g.sweep(ctx); err != nil {
+			klog.ErrorS(err, "Failed to sweep orphaned forwarding rules")
+		}
+	}, g.interval)
+}
+
+func (g *loadBalancerGC) sweep(ctx context.Context) error {
+	// services are listed before forwarding rules, so rules created in the
+	// meantime are at most considered orphaned once
+	referencedRules, referencedPorts, err := g.referencedForwardingRules(ctx)
+	if err != nil {
+		return err
+	}
+
+	createdRules, err := createdForwardingRules(ctx, g.clients)
+	if err != nil {
+		return err
+	}
+
+	loadBalancerClusters, _, err := g.clients.xelon().LoadBalancerClusters.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	orphans := sets.New[string]()
+	// gone keeps keys of recorded rules, which do not exist anymore
+	gone := sets.New[string]()
+	// unrecorded keeps keys of referenced rules, which are not recorded yet,
+	// e.g. because they were created by an earlier version
+	unrecorded := sets.New[string]()
+	for _, loadBalancerCluster := range loadBalancerClusters {
+		if loadBalancerCluster.KubernetesClusterID != g.clusterID || loadBalancerCluster.Status != xelonLoadBalancerClusterStatusActive {
+			continue
+		}
+		virtualIPs, _, err := g.clients.xelon().LoadBalancerClusters.ListVirtualIPs(ctx, loadBalancerCluster.ID)
+		if err != nil {
+			return err
+		}
+		for _, virtualIP := range virtualIPs {
+			forwardingRules, _, err := g.clients.xelon().LoadBalancerClusters.ListForwardingRules(ctx, loadBalancerCluster.ID, virtualIP.ID)
+			if err != nil {
+				return err
+			}
+			prefix := forwardingRuleKey(loadBalancerCluster.ID, virtualIP.ID, "")
+			for key := range createdRules {
+				if strings.HasPrefix(key, prefix) {
+					gone.Insert(key)
+				}
+			}
+			for _, forwardingRule := range forwardingRules {
+				if forwardingRule.Frontend == nil || forwardingRule.Frontend.ID == "" {
+					continue
+				}
+				key := forwardingRuleKey(loadBalancerCluster.ID, virtualIP.ID, forwardingRule.Frontend.ID)
+				gone.Delete(key)
+				if referencedRules.Has(key) && !createdRules.Has(key) {
+					unrecorded.Insert(key)
+					continue
+				}
+				// rules created by others are never deleted
+				if !createdRules.Has(key) {
+					continue
+				}
+				if referencedRules.Has(key) || referencedPorts.Has(virtualIPPortKey(loadBalancerCluster.ID, virtualIP.ID, forwardingRule.Frontend.Port)) {
+					continue
+				}
+				if !g.orphans.Has(key) {
+					klog.InfoS("Found orphaned forwarding rule, it is deleted if still orphaned in the next sweep",
+						"load_balancer_cluster_id", loadBalancerCluster.ID, "virtual_ip_id", virtualIP.ID, "forwarding_rule_id", forwardingRule.Frontend.ID, "port", forwardingRule.Frontend.Port)
+					orphans.Insert(key)
+					continue
+				}
+				if g.dryRun {
+					klog.InfoS("Dry-run: skip deleting orphaned forwarding rule",
+						"load_balancer_cluster_id", loadBalancerCluster.ID, "virtual_ip_id", virtualIP.ID, "forwarding_rule_id", forwardingRule.Frontend.ID, "port", forwardingRule.Frontend.Port)
+					orphans.Insert(key)
+					continue
+				}
+
+				klog.InfoS("Deleting orphaned forwarding rule",
+					"load_balancer_cluster_id", loadBalancerCluster.ID, "virtual_ip_id", virtualIP.ID, "forwarding_rule_id", forwardingRule.Frontend.ID, "port", forwardingRule.Frontend.Port)
+				resp, err := g.clients.xelon().LoadBalancerClusters.DeleteForwardingRule(ctx, loadBalancerCluster.ID, virtualIP.ID, forwardingRule.Frontend.ID)
+				if err != nil && (resp == nil || resp.StatusCode != http.StatusNotFound) {
+					klog.ErrorS(err, "Failed to delete orphaned forwarding rule", "forwarding_rule_id", forwardingRule.Frontend.ID)
+					orphans.Insert(key)
+					continue
+				}
+				loadBalancerGCDeletedForwardingRulesTotal.Inc()
+				gone.Insert(key)
+			}
+		}
+	}
+	g.orphans = orphans
+
+	if g.dryRun {
+		return nil
+	}
+	if unrecorded.Len() > 0 {
+		klog.InfoS("Recording forwarding rules referenced by services as created", "count", unrecorded.Len())
+		if err := recordCreatedForwardingRuleKeys(ctx, g.clients, sets.List(unrecorded)...); err != nil {
+			return err
+		}
+	}
+	return forgetCreatedForwardingRules(ctx, g.clients, gone)
+}
+
+// referencedForwardingRules returns keys of forwarding rules referenced by
+// service annotations (including rules of migrated services) or retained after
+// their services were deleted, and keys of ports exposed by services on their
+// virtual IPs. Rules on such ports are kept even if their ids are not yet annotated.
+func (g *loadBalancerGC) referencedForwardingRules(ctx context.Context) (sets.Set[string], sets.Set[string], error) {
+	services, err := g.clients.k8s.CoreV1().Services(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rules, ports := sets.New[string](), sets.New[string]()
+	for _, service := range services.Items {
+		// rules on the virtual IP the service is migrated from are kept until the migration is completed
+		sourceClusterID := service.Annotations[serviceAnnotationLoadBalancerMigrationSourceClusterID]
+		sourceVirtualIPID := service.Annotations[serviceAnnotationLoadBalancerMigrationSourceVirtualIPID]
+		for _, id := range splitAnnotationValue(service.Annotations[serviceAnnotationLoadBalancerMigrationSourceForwardingRuleIDs]) {
+			rules.Insert(forwardingRuleKey(sourceClusterID, sourceVirtualIPID, strings.TrimSpace(id)))
+		}
+
+		clusterID := service.Annotations[serviceAnnotationLoadBalancerClusterID]
+		virtualIPID := service.Annotations[serviceAnnotationLoadBalancerClusterVirtualIPID]
+		if clusterID == "" || virtualIPID == "" {
+			continue
+		}
+		for _, id := range splitAnnotationValue(service.Annotations[serviceAnnotationLoadBalancerClusterForwardingRuleIDs]) {
+			rules.Insert(forwardingRuleKey(clusterID, virtualIPID, strings.TrimSpace(id)))
+		}
+		for _, port := range service.Spec.Ports {
+			ports.Insert(virtualIPPortKey(clusterID, virtualIPID, int(port.Port)))
+		}
+	}
+
+	retained, err := retainedForwardingRules(ctx, g.clients)
+	if err != nil {
+		return nil, nil, err
+	}
+	rules.Insert(retained...)
+
+	return rules, ports, nil
+}
+
+func forwardingRuleKey(clusterID, virtualIPID, forwardingRuleID string) string {
+	return clusterID + "/" + virtualIPID + "/" + forwardingRuleID
+}
+
+func virtualIPPortKey(clusterID, virtualIPID string, port int) string {
+	return clusterID + "/" + virtualIPID + "/" + strconv.Itoa(port)
+}
@@ -0,0 +1,134 @@
+package xelon
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/Xelon-AG/xelon-sdk-go/xelon"
+)
+
+func TestLoadBalancerGC_sweep(t *testing.T) {
+	type testCase struct {
+		dryRun          bool
+		expectedDeleted []string
+		expectedCreated string
+	}
+	tests := map[string]testCase{
+		"delete orphaned rule in second sweep and record referenced rules": {
+			expectedDeleted: []string{"/lb-clusters/lb-1/virtual-ips/vip-1/forwarding-rules/orphaned"},
+			expectedCreated: "lb-1/vip-1/legacy\nlb-1/vip-1/migrating\nlb-1/vip-1/not-yet-annotated\nlb-1/vip-1/referenced\nlb-1/vip-1/retained\nlb-3/vip-3/unknown",
+		},
+		"dry-run": {
+			dryRun:          true,
+			expectedCreated: "lb-1/vip-1/gone\nlb-1/vip-1/migrating\nlb-1/vip-1/not-yet-annotated\nlb-1/vip-1/orphaned\nlb-1/vip-1/referenced\nlb-1/vip-1/retained\nlb-3/vip-3/unknown",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			writeJSON := func(w http.ResponseWriter, v any) {
+				assert.NoError(t, json.NewEncoder(w).Encode(v))
+			}
+			var mu sync.Mutex
+			var deleted []string
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /lb-clusters", func(w http.ResponseWriter, _ *http.Request) {
+				writeJSON(w, []xelon.LoadBalancerCluster{
+					{ID: "lb-1", Status: xelonLoadBalancerClusterStatusActive, KubernetesClusterID: "cluster-id"},
+					{ID: "lb-2", Status: xelonLoadBalancerClusterStatusActive, KubernetesClusterID: "other-cluster-id"},
+				})
+			})
+			mux.HandleFunc("GET /lb-clusters/lb-1/virtual-ips", func(w http.ResponseWriter, _ *http.Request) {
+				writeJSON(w, []xelon.LoadBalancerClusterVirtualIP{{ID: "vip-1"}})
+			})
+			mux.HandleFunc("GET /lb-clusters/lb-1/virtual-ips/vip-1/forwarding-rules", func(w http.ResponseWriter, _ *http.Request) {
+				writeJSON(w, []xelon.LoadBalancerClusterForwardingRule{
+					{Frontend: &xelon.LoadBalancerClusterForwardingRuleFrontendConfiguration{ID: "referenced", Port: 80}},
+					{Frontend: &xelon.LoadBalancerClusterForwardingRuleFrontendConfiguration{ID: "not-yet-annotated", Port: 443}},
+					{Frontend: &xelon.LoadBalancerClusterForwardingRuleFrontendConfiguration{ID: "orphaned", Port: 8080}},
+					{Frontend: &xelon.LoadBalancerClusterForwardingRuleFrontendConfiguration{ID: "migrating", Port: 9090}},
+					{Frontend: &xelon.LoadBalancerClusterForwardingRuleFrontendConfiguration{ID: "retained", Port: 7070}},
+					{Frontend: &xelon.LoadBalancerClusterForwardingRuleFrontendConfiguration{ID: "not-created", Port: 6060}},
+					{Frontend: &xelon.LoadBalancerClusterForwardingRuleFrontendConfiguration{ID: "legacy", Port: 81}},
+				})
+			})
+			mux.HandleFunc("GET /lb-clusters/lb-2/", func(w http.ResponseWriter, r *http.Request) {
+				t.Errorf("unexpected request for load balancer cluster of other cluster: %s", r.URL.Path)
+			})
+			mux.HandleFunc("DELETE /lb-clusters/", func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+				deleted = append(deleted, r.URL.Path)
+				w.WriteHeader(http.StatusNoContent)
+			})
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			service := &v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "service",
+					Namespace: "default",
+					Annotations: map[string]string{
+						serviceAnnotationLoadBalancerClusterID:                "lb-1",
+						serviceAnnotationLoadBalancerClusterVirtualIPID:       "vip-1",
+						serviceAnnotationLoadBalancerClusterForwardingRuleIDs: "referenced,legacy",
+					},
+				},
+				Spec: v1.ServiceSpec{Ports: []v1.ServicePort{{Port: 80}, {Port: 81}, {Port: 443}}},
+			}
+			migratedService := &v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "migrated-service",
+					Namespace: "default",
+					Annotations: map[string]string{
+						serviceAnnotationLoadBalancerClusterID:                        "lb-3",
+						serviceAnnotationLoadBalancerClusterVirtualIPID:               "vip-3",
+						serviceAnnotationLoadBalancerMigrationSourceClusterID:         "lb-1",
+						serviceAnnotationLoadBalancerMigrationSourceVirtualIPID:       "vip-1",
+						serviceAnnotationLoadBalancerMigrationSourceForwardingRuleIDs: "migrating",
+					},
+				},
+				Spec: v1.ServiceSpec{Ports: []v1.ServicePort{{Port: 9090}}},
+			}
+			retained := &v1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      retainedLoadBalancersConfigMapName,
+					Namespace: metav1.NamespaceSystem,
+				},
+				Data: map[string]string{
+					"default.deleted-service": `{"clusterId":"lb-1","virtualIpId":"vip-1","forwardingRuleIds":["retained"]}`,
+					"default.malformed":       `{"clusterId":`,
+				},
+			}
+			created := &v1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      createdForwardingRulesConfigMapName,
+					Namespace: metav1.NamespaceSystem,
+				},
+				Data: map[string]string{createdForwardingRulesKey: "lb-1/vip-1/gone\nlb-1/vip-1/migrating\nlb-1/vip-1/not-yet-annotated\nlb-1/vip-1/orphaned\nlb-1/vip-1/referenced\nlb-1/vip-1/retained\nlb-3/vip-3/unknown"},
+			}
+			c := newClients(xelon.NewClient("token", xelon.WithBaseURL(server.URL+"/")))
+			c.k8s = fake.NewClientset(service, migratedService, retained, created)
+			g := newLoadBalancerGC(c, "cluster-id", loadBalancersConfig{DryRun: test.dryRun})
+
+			assert.NoError(t, g.sweep(context.Background()))
+			assert.Empty(t, deleted, "rules must not be deleted in the first sweep")
+
+			assert.NoError(t, g.sweep(context.Background()))
+			assert.Equal(t, test.expectedDeleted, deleted)
+
+			configMap, err := c.k8s.CoreV1().ConfigMaps(metav1.NamespaceSystem).Get(context.Background(), createdForwardingRulesConfigMapName, metav1.GetOptions{})
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedCreated, configMap.Data[createdForwardingRulesKey])
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package xelon
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// createdForwardingRulesConfigMapName is the name of the config map in the
+	// namespace of the cloud controller manager recording forwarding rules
+	// created by the cloud controller manager. Forwarding rules have neither
+	// names nor tags, so only recorded rules are ever garbage collected.
+	createdForwardingRulesConfigMapName = "xelon-created-forwarding-rules"
+
+	// createdForwardingRulesKey holds newline separated forwarding rule keys.
+	createdForwardingRulesKey = "forwardingRules"
+)
+
+// recordCreatedForwardingRules adds forwarding rules of the virtual IP to the
+// ledger of rules created by the cloud controller manager.
+func recordCreatedForwardingRules(ctx context.Context, c *clients, clusterID, virtualIPID string, forwardingRuleIDs []string) error {
+	keys := make([]string, 0, len(forwardingRuleIDs))
+	for _, id := range forwardingRuleIDs {
+		keys = append(keys, forwardingRuleKey(clusterID, virtualIPID, id))
+	}
+	return recordCreatedForwardingRuleKeys(ctx, c, keys...)
+}
+
+// recordCreatedForwardingRuleKeys adds forwarding rule keys to the ledger of
+// rules created by the cloud controller manager.
+func recordCreatedForwardingRuleKeys(ctx context.Context, c *clients, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMaps := c.k8s.CoreV1().ConfigMaps(c.namespace)
+		configMap, err := configMaps.Get(ctx, createdForwardingRulesConfigMapName, metav1.GetOptions{})
+		if k8serrors.IsNotFound(err) {
+			configMap = &v1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      createdForwardingRulesConfigMapName,
+					Namespace: c.namespace,
+				},
+				Data: map[string]string{createdForwardingRulesKey: joinForwardingRuleKeys(sets.New(keys...))},
+			}
+			_, err = configMaps.Create(ctx, configMap, metav1.CreateOptions{})
+			if k8serrors.IsAlreadyExists(err) {
+				// retried as conflict, the config map was created concurrently
+				return k8serrors.NewConflict(v1.Resource("configmaps"), createdForwardingRulesConfigMapName, err)
+			}
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		recorded := splitForwardingRuleKeys(configMap)
+		if recorded.HasAll(keys...) {
+			return nil
+		}
+		if configMap.Data == nil {
+			configMap.Data = map[string]string{}
+		}
+		configMap.Data[createdForwardingRulesKey] = joinForwardingRuleKeys(recorded.Insert(keys...))
+		_, err = configMaps.Update(ctx, configMap, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record created forwarding rules in config map %s/%s: %w", c.namespace, createdForwardingRulesConfigMapName, err)
+	}
+	return nil
+}
+
+// createdForwardingRules returns keys of forwarding rules recorded as created
+// by the cloud controller manager.
+func createdForwardingRules(ctx context.Context, c *clients) (sets.Set[string], error) {
+	configMap, err := c.k8s.CoreV1().ConfigMaps(c.namespace).Get(ctx, createdForwardingRulesConfigMapName, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return sets.New[string](), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return splitForwardingRuleKeys(configMap), nil
+}
+
+// forgetCreatedForwardingRules removes forwarding rules, which do not exist
+// anymore, from the ledger.
+func forgetCreatedForwardingRules(ctx context.Context, c *clients, keys sets.Set[string]) error {
+	if keys.Len() == 0 {
+		return nil
+	}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMaps := c.k8s.CoreV1().ConfigMaps(c.namespace)
+		configMap, err := configMaps.Get(ctx, createdForwardingRulesConfigMapName, metav1.GetOptions{})
+		if k8serrors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		recorded := splitForwardingRuleKeys(configMap)
+		if !recorded.HasAny(keys.UnsortedList()...) {
+			return nil
+		}
+		configMap.Data[createdForwardingRulesKey] = joinForwardingRuleKeys(recorded.Difference(keys))
+		_, err = configMaps.Update(ctx, configMap, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+func splitForwardingRuleKeys(configMap *v1.ConfigMap) sets.Set[string] {
+	keys := sets.New[string]()
+	for _, key := range strings.Split(configMap.Data[createdForwardingRulesKey], "\n") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys.Insert(key)
+		}
+	}
+	return keys
+}
+
+func joinForwardingRuleKeys(keys sets.Set[string]) string {
+	return strings.Join(sets.List(keys), "\n")
+}
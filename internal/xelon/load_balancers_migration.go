@@ -0,0 +1,313 @@
+package xelon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+)
+
+// startMigration moves the service to the load balancer cluster and virtual IP
+// requested with target annotations. The current cluster, virtual IP and
+// forwarding rules are remembered in migration source annotations, so rules on
+// the source virtual IP are deleted only after rules on the target virtual IP
+// are created (see completeMigration). Services without load balancer are
+// placed on the target directly.
+func (l *loadBalancers) startMigration(ctx context.Context, service *v1.Service) error {
+	logger := configureLogger(ctx, "startMigration").WithValues(
+		"service", getServiceNameWithNamespace(service),
+	)
+
+	annotations, err := parseServiceAnnotations(service.Annotations)
+	if err != nil {
+		return fmt.Errorf("invalid service annotations: %w", err)
+	}
+	if annotations.targetClusterID == "" && annotations.targetVirtualIPID == "" {
+		return nil
+	}
+	if annotations.isMigrating() {
+		logger.Info("Migration is already in progress", "source_cluster_id", annotations.migrationSourceClusterID, "source_virtual_ip_id", annotations.migrationSourceVirtualIPID)
+		return nil
+	}
+
+	targetClusterID := annotations.targetClusterID
+	if targetClusterID == "" {
+		targetClusterID = annotations.loadBalancerClusterID
+	}
+	if targetClusterID == "" {
+		l.client.recordEventf(service, v1.EventTypeWarning, eventReasonInvalidAnnotations,
+			"Annotation %s requires %s for services without load balancer cluster", serviceAnnotationLoadBalancerTargetVirtualIPID, serviceAnnotationLoadBalancerTargetClusterID,
+		)
+		return fmt.Errorf("annotation %s requires %s for services without load balancer cluster", serviceAnnotationLoadBalancerTargetVirtualIPID, serviceAnnotationLoadBalancerTargetClusterID)
+	}
+	if targetClusterID == annotations.loadBalancerClusterID && (annotations.targetVirtualIPID == "" || annotations.targetVirtualIPID == annotations.virtualIPID) {
+		return nil
+	}
+	if err := l.checkMigrationTarget(ctx, service, targetClusterID, annotations.targetVirtualIPID); err != nil {
+		return err
+	}
+
+	if l.config.DryRun {
+		l.client.recordEventf(service, v1.EventTypeNormal, eventReasonLoadBalancerDryRun,
+			"Dry-run: would migrate load balancer to cluster %s and virtual IP %s", targetClusterID, formatTargetVirtualIP(annotations.targetVirtualIPID),
+		)
+		return nil
+	}
+
+	patcher := newServicePatcher(l.client.k8s, service, l.config.DryRun)
+	if annotations.loadBalancerClusterID != "" && annotations.virtualIPID != "" {
+		logger.Info("Migrating load balancer",
+			"source_cluster_id", annotations.loadBalancerClusterID, "source_virtual_ip_id", annotations.virtualIPID,
+			"target_cluster_id", targetClusterID, "target_virtual_ip_id", annotations.targetVirtualIPID,
+		)
+		updateServiceAnnotation(service, serviceAnnotationLoadBalancerMigrationSourceClusterID, annotations.loadBalancerClusterID)
+		updateServiceAnnotation(service, serviceAnnotationLoadBalancerMigrationSourceVirtualIPID, annotations.virtualIPID)
+		updateServiceAnnotation(service, serviceAnnotationLoadBalancerMigrationSourceForwardingRuleIDs, strings.Join(annotations.forwardingRuleIDs, ","))
+		l.client.recordEventf(service, v1.EventTypeNormal, eventReasonLoadBalancerMigrating,
+			"Migrating load balancer from cluster %s and virtual IP %s to cluster %s and virtual IP %s",
+			annotations.loadBalancerClusterID, annotations.virtualIPID, targetClusterID, formatTargetVirtualIP(annotations.targetVirtualIPID),
+		)
+	}
+	updateServiceAnnotation(service, serviceAnnotationLoadBalancerClusterID, targetClusterID)
+	if annotations.targetVirtualIPID != "" {
+		updateServiceAnnotation(service, serviceAnnotationLoadBalancerClusterVirtualIPID, annotations.targetVirtualIPID)
+	} else {
+		delete(service.Annotations, serviceAnnotationLoadBalancerClusterVirtualIPID)
+	}
+	delete(service.Annotations, serviceAnnotationLoadBalancerClusterForwardingRuleIDs)
+
+	return patcher.Patch(ctx)
+}
+
+// checkMigrationTarget makes sure the target load balancer cluster belongs to
+// the Kubernetes cluster and the target virtual IP is not used by another
+// service, so a service can never be moved onto foreign forwarding rules.
+func (l *loadBalancers) checkMigrationTarget(ctx context.Context, service *v1.Service, targetClusterID, targetVirtualIPID string) error {
+	refuse := func(format string, args ...any) error {
+		message := fmt.Sprintf(format, args...)
+		l.client.recordEventf(service, v1.EventTypeWarning, eventReasonLoadBalancerMigrationRefused, "Refusing to migrate load balancer: %s", message)
+		return fmt.Errorf("refusing to migrate load balancer: %s", message)
+	}
+
+	loadBalancerCluster, err := l.fetchXelonLoadBalancerCluster(ctx, targetClusterID)
+	if err != nil {
+		if errors.Is(err, errLoadBalancerNotFound) {
+			return refuse("load balancer cluster %s does not exist", targetClusterID)
+		}
+		return err
+	}
+	if loadBalancerCluster.KubernetesClusterID != l.clusterID {
+		return refuse("load balancer cluster %s does not belong to Kubernetes cluster %s", targetClusterID, l.clusterID)
+	}
+	if targetVirtualIPID == "" {
+		return nil
+	}
+
+	services, err := l.client.k8s.CoreV1().Services(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, other := range services.Items {
+		if other.UID == service.UID {
+			continue
+		}
+		otherAnnotations := parseControllerAnnotations(other.Annotations)
+		if (otherAnnotations.loadBalancerClusterID == targetClusterID && otherAnnotations.virtualIPID == targetVirtualIPID) ||
+			(otherAnnotations.migrationSourceClusterID == targetClusterID && otherAnnotations.migrationSourceVirtualIPID == targetVirtualIPID) {
+			return refuse("virtual IP %s is used by service %s", targetVirtualIPID, getServiceNameWithNamespace(&other))
+		}
+	}
+
+	return nil
+}
+
+// completeMigration deletes forwarding rules on the source virtual IP once
+// rules on the target virtual IP are created. If a drain period is requested,
+// the deadline is stored on the first call and completing the migration is
+// scheduled for the deadline, so the new status is published meanwhile.
+func (l *loadBalancers) completeMigration(ctx context.Context, service *v1.Service) error {
+	logger := configureLogger(ctx, "completeMigration").WithValues(
+		"service", getServiceNameWithNamespace(service),
+	)
+
+	annotations, err := parseServiceAnnotations(service.Annotations)
+	if err != nil {
+		return fmt.Errorf("invalid service annotations: %w", err)
+	}
+	if !annotations.isMigrating() || l.config.DryRun {
+		return nil
+	}
+
+	patcher := newServicePatcher(l.client.k8s, service, l.config.DryRun)
+
+	now := l.now()
+	if annotations.migrationDrainUntil == nil && annotations.migrationDrainPeriod != nil && *annotations.migrationDrainPeriod > 0 {
+		drainUntil := now.Add(*annotations.migrationDrainPeriod).UTC().Truncate(time.Second)
+		logger.Info("Draining forwarding rules on source virtual IP", "source_virtual_ip_id", annotations.migrationSourceVirtualIPID, "drain_until", drainUntil)
+		updateServiceAnnotation(service, serviceAnnotationLoadBalancerMigrationDrainUntil, drainUntil.Format(time.RFC3339))
+		if err := patcher.Patch(ctx); err != nil {
+			return err
+		}
+		l.scheduleCompleteMigration(service, drainUntil.Sub(now))
+		return nil
+	}
+	if annotations.migrationDrainUntil != nil && now.Before(*annotations.migrationDrainUntil) {
+		remaining := annotations.migrationDrainUntil.Sub(now)
+		logger.Info("Forwarding rules on source virtual IP are still draining", "source_virtual_ip_id", annotations.migrationSourceVirtualIPID, "complete_after", remaining)
+		l.scheduleCompleteMigration(service, remaining)
+		return nil
+	}
+
+	if err := l.deleteMigrationSourceForwardingRules(ctx, service); err != nil {
+		return err
+	}
+
+	delete(service.Annotations, serviceAnnotationLoadBalancerMigrationSourceClusterID)
+	delete(service.Annotations, serviceAnnotationLoadBalancerMigrationSourceVirtualIPID)
+	delete(service.Annotations, serviceAnnotationLoadBalancerMigrationSourceForwardingRuleIDs)
+	delete(service.Annotations, serviceAnnotationLoadBalancerMigrationDrainUntil)
+	if err := patcher.Patch(ctx); err != nil {
+		return err
+	}
+
+	l.client.recordEventf(service, v1.EventTypeNormal, eventReasonLoadBalancerMigrated,
+		"Migrated load balancer from cluster %s and virtual IP %s to cluster %s and virtual IP %s",
+		annotations.migrationSourceClusterID, annotations.migrationSourceVirtualIPID, annotations.loadBalancerClusterID, annotations.virtualIPID,
+	)
+
+	return nil
+}
+
+// deleteMigrationSourceForwardingRules deletes forwarding rules on the virtual
+// IP the service is migrated from. Already deleted rules are skipped.
+func (l *loadBalancers) deleteMigrationSourceForwardingRules(ctx context.Context, service *v1.Service) error {
+	logger := configureLogger(ctx, "deleteMigrationSourceForwardingRules").WithValues(
+		"service", getServiceNameWithNamespace(service),
+	)
+
+	annotations := parseControllerAnnotations(service.Annotations)
+	if !annotations.isMigrating() {
+		return nil
+	}
+
+	if l.config.DryRun {
+		l.client.recordEventf(service, v1.EventTypeNormal, eventReasonLoadBalancerDryRun,
+			"Dry-run: would delete forwarding rules %s on source virtual IP %s",
+			strings.Join(annotations.migrationSourceForwardingRuleIDs, ","), annotations.migrationSourceVirtualIPID,
+		)
+		return nil
+	}
+
+	for _, id := range annotations.migrationSourceForwardingRuleIDs {
+		logger.Info("Deleting forwarding rule on source virtual IP", "cluster_id", annotations.migrationSourceClusterID, "virtual_ip_id", annotations.migrationSourceVirtualIPID, "forwarding_rule_id", id)
+		resp, err := l.client.xelon().LoadBalancerClusters.DeleteForwardingRule(ctx, annotations.migrationSourceClusterID, annotations.migrationSourceVirtualIPID, id)
+		if err != nil {
+			if resp != nil && resp.StatusCode == http.StatusNotFound {
+				logger.Info("Skipped removing not existing forwarding rule", "forwarding_rule_id", id)
+				continue
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// scheduleCompleteMigration completes the migration of the service once
+// forwarding rules on the source virtual IP are drained. The service
+// controller does not requeue services returning a status, so the service
+// is fetched again and completed by the cloud controller manager itself.
+func (l *loadBalancers) scheduleCompleteMigration(service *v1.Service, after time.Duration) {
+	namespace, name, uid := service.Namespace, service.Name, service.UID
+	l.drains.schedule(uid, after, func(ctx context.Context) {
+		// serialized with the service controller updating or deleting the load balancer
+		l.Lock()
+		defer l.Unlock()
+
+		current, err := l.client.k8s.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			if !k8serrors.IsNotFound(err) {
+				klog.ErrorS(err, "Failed to get service to complete migration", "service", namespace+"/"+name)
+			}
+			return
+		}
+		if current.UID != uid || current.DeletionTimestamp != nil {
+			return
+		}
+		if err := l.completeMigration(ctx, current); err != nil {
+			klog.ErrorS(err, "Failed to complete migration", "service", namespace+"/"+name)
+		}
+	})
+}
+
+// migrationDrains keeps timers completing migrations of services, whose
+// forwarding rules on the source virtual IP are drained.
+type migrationDrains struct {
+	mu     sync.Mutex
+	ctx    context.Context
+	timers map[types.UID]*time.Timer
+}
+
+func newMigrationDrains() *migrationDrains {
+	return &migrationDrains{ctx: context.Background(), timers: make(map[types.UID]*time.Timer)}
+}
+
+// run passes ctx to scheduled functions and stops all timers once ctx is done.
+func (d *migrationDrains) run(ctx context.Context) {
+	d.mu.Lock()
+	d.ctx = ctx
+	d.mu.Unlock()
+
+	<-ctx.Done()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for uid, timer := range d.timers {
+		timer.Stop()
+		delete(d.timers, uid)
+	}
+}
+
+// schedule runs f after the duration, unless it is already scheduled for the service.
+func (d *migrationDrains) schedule(uid types.UID, after time.Duration, f func(ctx context.Context)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.timers[uid]; ok || d.ctx.Err() != nil {
+		return
+	}
+	d.timers[uid] = time.AfterFunc(after, func() {
+		d.mu.Lock()
+		delete(d.timers, uid)
+		ctx := d.ctx
+		d.mu.Unlock()
+		f(ctx)
+	})
+}
+
+// cancel stops completing the migration of the service, e.g. once it is deleted.
+func (d *migrationDrains) cancel(uid types.UID) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if timer, ok := d.timers[uid]; ok {
+		timer.Stop()
+		delete(d.timers, uid)
+	}
+}
+
+func formatTargetVirtualIP(virtualIPID string) string {
+	if virtualIPID == "" {
+		return "<any available>"
+	}
+	return virtualIPID
+}
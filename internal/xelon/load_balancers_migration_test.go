@@ -0,0 +1,355 @@
+package xelon
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/Xelon-AG/xelon-sdk-go/xelon"
+)
+
+func TestLoadBalancers_startMigration(t *testing.T) {
+	current := map[string]string{
+		serviceAnnotationLoadBalancerClusterID:                "lb-1",
+		serviceAnnotationLoadBalancerClusterVirtualIPID:       "vip-1",
+		serviceAnnotationLoadBalancerClusterForwardingRuleIDs: "rule-1,rule-2",
+	}
+
+	type testCase struct {
+		annotations   map[string]string
+		expected      map[string]string
+		expectedErr   bool
+		expectedEvent string
+	}
+	tests := map[string]testCase{
+		"no target": {
+			annotations: current,
+			expected:    current,
+		},
+		"target is current virtual ip": {
+			annotations: mergeAnnotations(current, map[string]string{serviceAnnotationLoadBalancerTargetVirtualIPID: "vip-1"}),
+			expected:    mergeAnnotations(current, map[string]string{serviceAnnotationLoadBalancerTargetVirtualIPID: "vip-1"}),
+		},
+		"target virtual ip on current cluster": {
+			annotations: mergeAnnotations(current, map[string]string{serviceAnnotationLoadBalancerTargetVirtualIPID: "vip-2"}),
+			expected: map[string]string{
+				serviceAnnotationLoadBalancerTargetVirtualIPID:                "vip-2",
+				serviceAnnotationLoadBalancerClusterID:                        "lb-1",
+				serviceAnnotationLoadBalancerClusterVirtualIPID:               "vip-2",
+				serviceAnnotationLoadBalancerMigrationSourceClusterID:         "lb-1",
+				serviceAnnotationLoadBalancerMigrationSourceVirtualIPID:       "vip-1",
+				serviceAnnotationLoadBalancerMigrationSourceForwardingRuleIDs: "rule-1,rule-2",
+			},
+			expectedEvent: "Normal XelonLoadBalancerMigrating Migrating load balancer from cluster lb-1 and virtual IP vip-1 to cluster lb-1 and virtual IP vip-2",
+		},
+		"target cluster": {
+			annotations: mergeAnnotations(current, map[string]string{serviceAnnotationLoadBalancerTargetClusterID: "lb-2"}),
+			expected: map[string]string{
+				serviceAnnotationLoadBalancerTargetClusterID:                  "lb-2",
+				serviceAnnotationLoadBalancerClusterID:                        "lb-2",
+				serviceAnnotationLoadBalancerMigrationSourceClusterID:         "lb-1",
+				serviceAnnotationLoadBalancerMigrationSourceVirtualIPID:       "vip-1",
+				serviceAnnotationLoadBalancerMigrationSourceForwardingRuleIDs: "rule-1,rule-2",
+			},
+			expectedEvent: "Normal XelonLoadBalancerMigrating Migrating load balancer from cluster lb-1 and virtual IP vip-1 to cluster lb-2 and virtual IP <any available>",
+		},
+		"migration in progress": {
+			annotations: mergeAnnotations(current, map[string]string{
+				serviceAnnotationLoadBalancerTargetClusterID:            "lb-2",
+				serviceAnnotationLoadBalancerMigrationSourceClusterID:   "lb-0",
+				serviceAnnotationLoadBalancerMigrationSourceVirtualIPID: "vip-0",
+			}),
+			expected: mergeAnnotations(current, map[string]string{
+				serviceAnnotationLoadBalancerTargetClusterID:            "lb-2",
+				serviceAnnotationLoadBalancerMigrationSourceClusterID:   "lb-0",
+				serviceAnnotationLoadBalancerMigrationSourceVirtualIPID: "vip-0",
+			}),
+		},
+		"new service is placed on target": {
+			annotations: map[string]string{
+				serviceAnnotationLoadBalancerTargetClusterID:   "lb-2",
+				serviceAnnotationLoadBalancerTargetVirtualIPID: "vip-2",
+			},
+			expected: map[string]string{
+				serviceAnnotationLoadBalancerTargetClusterID:    "lb-2",
+				serviceAnnotationLoadBalancerTargetVirtualIPID:  "vip-2",
+				serviceAnnotationLoadBalancerClusterID:          "lb-2",
+				serviceAnnotationLoadBalancerClusterVirtualIPID: "vip-2",
+			},
+		},
+		"target cluster of another kubernetes cluster": {
+			annotations:   mergeAnnotations(current, map[string]string{serviceAnnotationLoadBalancerTargetClusterID: "lb-3"}),
+			expected:      mergeAnnotations(current, map[string]string{serviceAnnotationLoadBalancerTargetClusterID: "lb-3"}),
+			expectedErr:   true,
+			expectedEvent: "Warning XelonLoadBalancerMigrationRefused Refusing to migrate load balancer: load balancer cluster lb-3 does not belong to Kubernetes cluster cluster-id",
+		},
+		"target cluster does not exist": {
+			annotations:   mergeAnnotations(current, map[string]string{serviceAnnotationLoadBalancerTargetClusterID: "lb-4"}),
+			expected:      mergeAnnotations(current, map[string]string{serviceAnnotationLoadBalancerTargetClusterID: "lb-4"}),
+			expectedErr:   true,
+			expectedEvent: "Warning XelonLoadBalancerMigrationRefused Refusing to migrate load balancer: load balancer cluster lb-4 does not exist",
+		},
+		"target virtual ip is used by another service": {
+			annotations:   mergeAnnotations(current, map[string]string{serviceAnnotationLoadBalancerTargetVirtualIPID: "vip-3"}),
+			expected:      mergeAnnotations(current, map[string]string{serviceAnnotationLoadBalancerTargetVirtualIPID: "vip-3"}),
+			expectedErr:   true,
+			expectedEvent: "Warning XelonLoadBalancerMigrationRefused Refusing to migrate load balancer: virtual IP vip-3 is used by service default/other-service",
+		},
+		"target virtual ip without cluster": {
+			annotations:   map[string]string{serviceAnnotationLoadBalancerTargetVirtualIPID: "vip-2"},
+			expected:      map[string]string{serviceAnnotationLoadBalancerTargetVirtualIPID: "vip-2"},
+			expectedErr:   true,
+			expectedEvent: "Warning XelonInvalidAnnotations Annotation service.beta.kubernetes.io/xelon-load-balancer-target-virtual-ip-id requires service.beta.kubernetes.io/xelon-load-balancer-target-cluster-id for services without load balancer cluster",
+		},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /lb-clusters/{id}", func(w http.ResponseWriter, r *http.Request) {
+		switch r.PathValue("id") {
+		case "lb-1", "lb-2":
+			_, _ = w.Write([]byte(`{"id":"` + r.PathValue("id") + `","kubernetesClusterId":"cluster-id"}`))
+		case "lb-3":
+			_, _ = w.Write([]byte(`{"id":"lb-3","kubernetesClusterId":"other-cluster-id"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			service := &v1.Service{ObjectMeta: metav1.ObjectMeta{
+				Name:        "service",
+				Namespace:   "default",
+				UID:         "uid",
+				Annotations: mergeAnnotations(test.annotations, nil),
+			}}
+			otherService := &v1.Service{ObjectMeta: metav1.ObjectMeta{
+				Name:      "other-service",
+				Namespace: "default",
+				UID:       "other-uid",
+				Annotations: map[string]string{
+					serviceAnnotationLoadBalancerClusterID:          "lb-1",
+					serviceAnnotationLoadBalancerClusterVirtualIPID: "vip-3",
+				},
+			}}
+			k8sClient := fake.NewClientset(service, otherService)
+			recorder := record.NewFakeRecorder(10)
+			c := newClients(xelon.NewClient("token", xelon.WithBaseURL(server.URL+"/")))
+			c.k8s = k8sClient
+			c.recorder = recorder
+			l := &loadBalancers{client: c, clusterID: "cluster-id", RWMutex: &sync.RWMutex{}}
+
+			err := l.startMigration(context.Background(), service)
+
+			if test.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			actual, err := k8sClient.CoreV1().Services("default").Get(context.Background(), "service", metav1.GetOptions{})
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, actual.Annotations)
+			if test.expectedEvent != "" {
+				assert.Equal(t, test.expectedEvent, <-recorder.Events)
+			} else {
+				assert.Empty(t, recorder.Events)
+			}
+		})
+	}
+}
+
+func TestLoadBalancers_completeMigration(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	migrating := map[string]string{
+		serviceAnnotationLoadBalancerClusterID:                        "lb-2",
+		serviceAnnotationLoadBalancerClusterVirtualIPID:               "vip-2",
+		serviceAnnotationLoadBalancerMigrationSourceClusterID:         "lb-1",
+		serviceAnnotationLoadBalancerMigrationSourceVirtualIPID:       "vip-1",
+		serviceAnnotationLoadBalancerMigrationSourceForwardingRuleIDs: "rule-1,rule-2",
+	}
+	migrated := map[string]string{
+		serviceAnnotationLoadBalancerClusterID:          "lb-2",
+		serviceAnnotationLoadBalancerClusterVirtualIPID: "vip-2",
+	}
+
+	type testCase struct {
+		annotations     map[string]string
+		expected        map[string]string
+		expectedDrain   bool
+		expectedDeleted []string
+		expectedEvent   string
+	}
+	tests := map[string]testCase{
+		"not migrating": {
+			annotations: migrated,
+			expected:    migrated,
+		},
+		"without drain period": {
+			annotations: migrating,
+			expected:    migrated,
+			expectedDeleted: []string{
+				"/lb-clusters/lb-1/virtual-ips/vip-1/forwarding-rules/rule-1",
+				"/lb-clusters/lb-1/virtual-ips/vip-1/forwarding-rules/rule-2",
+			},
+			expectedEvent: "Normal XelonLoadBalancerMigrated Migrated load balancer from cluster lb-1 and virtual IP vip-1 to cluster lb-2 and virtual IP vip-2",
+		},
+		"drain period starts": {
+			annotations: mergeAnnotations(migrating, map[string]string{serviceAnnotationLoadBalancerMigrationDrainPeriod: "10m"}),
+			expected: mergeAnnotations(migrating, map[string]string{
+				serviceAnnotationLoadBalancerMigrationDrainPeriod: "10m",
+				serviceAnnotationLoadBalancerMigrationDrainUntil:  "2024-01-01T00:10:00Z",
+			}),
+			expectedDrain: true,
+		},
+		"draining": {
+			annotations: mergeAnnotations(migrating, map[string]string{
+				serviceAnnotationLoadBalancerMigrationDrainPeriod: "10m",
+				serviceAnnotationLoadBalancerMigrationDrainUntil:  "2024-01-01T00:04:00Z",
+			}),
+			expected: mergeAnnotations(migrating, map[string]string{
+				serviceAnnotationLoadBalancerMigrationDrainPeriod: "10m",
+				serviceAnnotationLoadBalancerMigrationDrainUntil:  "2024-01-01T00:04:00Z",
+			}),
+			expectedDrain: true,
+		},
+		"drain period is over": {
+			annotations: mergeAnnotations(migrating, map[string]string{
+				serviceAnnotationLoadBalancerMigrationDrainPeriod: "10m",
+				serviceAnnotationLoadBalancerMigrationDrainUntil:  "2023-12-31T23:50:00Z",
+			}),
+			expected: mergeAnnotations(migrated, map[string]string{serviceAnnotationLoadBalancerMigrationDrainPeriod: "10m"}),
+			expectedDeleted: []string{
+				"/lb-clusters/lb-1/virtual-ips/vip-1/forwarding-rules/rule-1",
+				"/lb-clusters/lb-1/virtual-ips/vip-1/forwarding-rules/rule-2",
+			},
+			expectedEvent: "Normal XelonLoadBalancerMigrated Migrated load balancer from cluster lb-1 and virtual IP vip-1 to cluster lb-2 and virtual IP vip-2",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var deleted []string
+			mux := http.NewServeMux()
+			mux.HandleFunc("DELETE /lb-clusters/", func(w http.ResponseWriter, r *http.Request) {
+				deleted = append(deleted, r.URL.Path)
+				if r.URL.Path == "/lb-clusters/lb-1/virtual-ips/vip-1/forwarding-rules/rule-2" {
+					// already deleted rules are skipped
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				w.WriteHeader(http.StatusNoContent)
+			})
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			service := &v1.Service{ObjectMeta: metav1.ObjectMeta{
+				Name:        "service",
+				Namespace:   "default",
+				UID:         "uid",
+				Annotations: mergeAnnotations(test.annotations, nil),
+			}}
+			k8sClient := fake.NewClientset(service)
+			recorder := record.NewFakeRecorder(10)
+			c := newClients(xelon.NewClient("token", xelon.WithBaseURL(server.URL+"/")))
+			c.k8s = k8sClient
+			c.recorder = recorder
+			l := newLoadBalancers(c, &tenantResolver{id: "tenant-id", resolved: true}, "cloud-id", "cluster-id", loadBalancersConfig{}).(*loadBalancers)
+			l.now = func() time.Time { return now }
+
+			err := l.completeMigration(context.Background(), service)
+
+			// draining services are completed by the cloud controller manager, not requeued by retry errors
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedDrain, l.drains.timers["uid"] != nil)
+			l.drains.cancel("uid")
+			actual, err := k8sClient.CoreV1().Services("default").Get(context.Background(), "service", metav1.GetOptions{})
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, actual.Annotations)
+			assert.Equal(t, test.expectedDeleted, deleted)
+			if test.expectedEvent != "" {
+				assert.Equal(t, test.expectedEvent, <-recorder.Events)
+			} else {
+				assert.Empty(t, recorder.Events)
+			}
+		})
+	}
+}
+
+func mergeAnnotations(base, annotations map[string]string) map[string]string {
+	merged := maps.Clone(base)
+	if merged == nil {
+		merged = map[string]string{}
+	}
+	maps.Copy(merged, annotations)
+	return merged
+}
+
+func TestLoadBalancers_scheduleCompleteMigration(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("DELETE /lb-clusters/lb-1/virtual-ips/vip-1/forwarding-rules/rule-1", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	service := &v1.Service{ObjectMeta: metav1.ObjectMeta{
+		Name:      "service",
+		Namespace: "default",
+		UID:       "uid",
+		Annotations: map[string]string{
+			serviceAnnotationLoadBalancerClusterID:                        "lb-2",
+			serviceAnnotationLoadBalancerClusterVirtualIPID:               "vip-2",
+			serviceAnnotationLoadBalancerMigrationSourceClusterID:         "lb-1",
+			serviceAnnotationLoadBalancerMigrationSourceVirtualIPID:       "vip-1",
+			serviceAnnotationLoadBalancerMigrationSourceForwardingRuleIDs: "rule-1",
+			serviceAnnotationLoadBalancerMigrationDrainUntil:              "2024-01-01T00:00:00Z",
+		},
+	}}
+	k8sClient := fake.NewClientset(service)
+	c := newClients(xelon.NewClient("token", xelon.WithBaseURL(server.URL+"/")))
+	c.k8s = k8sClient
+	l := newLoadBalancers(c, &tenantResolver{id: "tenant-id", resolved: true}, "cloud-id", "cluster-id", loadBalancersConfig{}).(*loadBalancers)
+
+	l.scheduleCompleteMigration(service, 0)
+
+	assert.Eventually(t, func() bool {
+		actual, err := k8sClient.CoreV1().Services("default").Get(context.Background(), "service", metav1.GetOptions{})
+		return err == nil && actual.Annotations[serviceAnnotationLoadBalancerMigrationSourceClusterID] == ""
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestMigrationDrains_run(t *testing.T) {
+	d := newMigrationDrains()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		d.run(ctx)
+		close(done)
+	}()
+
+	d.schedule("uid", time.Hour, func(context.Context) {})
+	assert.Eventually(t, func() bool {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		return d.ctx == ctx
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-done
+
+	assert.Empty(t, d.timers)
+	d.schedule("uid", 0, func(context.Context) { t.Error("function scheduled after shutdown must not run") })
+	assert.Empty(t, d.timers)
+}
@@ -0,0 +1,91 @@
+package xelon
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// checkOwnership makes sure read-only annotations of the service were written
+// for this service and not copied from another one. Services annotated before
+// the owner annotation was introduced are adopted, unless another service
+// references the same forwarding rules. In that case ownership is ambiguous
+// and both services are refused until the copied annotations are removed.
+func (l *loadBalancers) checkOwnership(ctx context.Context, service *v1.Service) error {
+	logger := configureLogger(ctx, "checkOwnership").WithValues(
+		"service", getServiceNameWithNamespace(service),
+	)
+
+	// only read-only annotations are checked, so other invalid annotations
+	// never block deleting the service
+	annotations := parseControllerAnnotations(service.Annotations)
+	if !annotations.hasLoadBalancer() || annotations.owner == string(service.UID) {
+		return nil
+	}
+
+	if annotations.owner != "" {
+		l.client.recordEventf(service, v1.EventTypeWarning, eventReasonForeignAnnotations,
+			"Load balancer annotations belong to service with UID %s, refusing to manage load balancer. Remove %s annotations to provision a new load balancer",
+			annotations.owner, formatReadOnlyAnnotations(),
+		)
+		return fmt.Errorf("%w (owner %s)", errLoadBalancerForeignAnnotations, annotations.owner)
+	}
+
+	duplicate, err := l.findDuplicateService(ctx, service, annotations)
+	if err != nil {
+		return err
+	}
+	if duplicate != nil {
+		l.client.recordEventf(service, v1.EventTypeWarning, eventReasonForeignAnnotations,
+			"Load balancer annotations are also used by service %s, refusing to manage load balancer. Remove %s annotations from the service they were copied to",
+			getServiceNameWithNamespace(duplicate), formatReadOnlyAnnotations(),
+		)
+		return fmt.Errorf("%w (also used by service %s)", errLoadBalancerForeignAnnotations, getServiceNameWithNamespace(duplicate))
+	}
+
+	logger.Info("Adopting load balancer annotations", "uid", service.UID)
+	patcher := newServicePatcher(l.client.k8s, service, l.config.DryRun)
+	updateServiceAnnotation(service, serviceAnnotationLoadBalancerOwner, string(service.UID))
+	return patcher.Patch(ctx)
+}
+
+// findDuplicateService returns another service referencing the same
+// forwarding rules on the same virtual IP, or nil if there is none.
+func (l *loadBalancers) findDuplicateService(ctx context.Context, service *v1.Service, annotations *serviceAnnotations) (*v1.Service, error) {
+	if len(annotations.forwardingRuleIDs) == 0 {
+		return nil, nil
+	}
+
+	services, err := l.client.k8s.CoreV1().Services(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, other := range services.Items {
+		if other.UID == service.UID {
+			continue
+		}
+		otherAnnotations := parseControllerAnnotations(other.Annotations)
+		if otherAnnotations.loadBalancerClusterID != annotations.loadBalancerClusterID || otherAnnotations.virtualIPID != annotations.virtualIPID {
+			continue
+		}
+		if slices.ContainsFunc(otherAnnotations.forwardingRuleIDs, func(id string) bool {
+			return slices.Contains(annotations.forwardingRuleIDs, id)
+		}) {
+			return &other, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func formatReadOnlyAnnotations() string {
+	return fmt.Sprintf("%s, %s, %s and %s",
+		serviceAnnotationLoadBalancerClusterID,
+		serviceAnnotationLoadBalancerClusterVirtualIPID,
+		serviceAnnotationLoadBalancerClusterForwardingRuleIDs,
+		serviceAnnotationLoadBalancerOwner,
+	)
+}
@@ -0,0 +1,128 @@
+package xelon
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestLoadBalancers_checkOwnership(t *testing.T) {
+	newService := func(name, uid string, annotations map[string]string) *v1.Service {
+		return &v1.Service{ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   "default",
+			UID:         types.UID("uid-" + uid),
+			Annotations: annotations,
+		}}
+	}
+	readOnlyAnnotations := func(owner string) map[string]string {
+		annotations := map[string]string{
+			serviceAnnotationLoadBalancerClusterID:                "lb-1",
+			serviceAnnotationLoadBalancerClusterVirtualIPID:       "vip-1",
+			serviceAnnotationLoadBalancerClusterForwardingRuleIDs: "rule-1,rule-2",
+		}
+		if owner != "" {
+			annotations[serviceAnnotationLoadBalancerOwner] = owner
+		}
+		return annotations
+	}
+
+	type testCase struct {
+		service       *v1.Service
+		others        []*v1.Service
+		expectedErr   bool
+		expectedOwner string
+		expectedEvent string
+	}
+	tests := map[string]testCase{
+		"no load balancer annotations": {
+			service: newService("service", "1", nil),
+		},
+		"owned": {
+			service:       newService("service", "1", readOnlyAnnotations("uid-1")),
+			expectedOwner: "uid-1",
+		},
+		"copied from another service": {
+			service:       newService("copy", "2", readOnlyAnnotations("uid-1")),
+			expectedErr:   true,
+			expectedOwner: "uid-1",
+			expectedEvent: "Warning XelonForeignAnnotations Load balancer annotations belong to service with UID uid-1, refusing to manage load balancer.",
+		},
+		"legacy service is adopted": {
+			service:       newService("service", "1", readOnlyAnnotations("")),
+			others:        []*v1.Service{newService("other", "2", map[string]string{serviceAnnotationLoadBalancerClusterID: "lb-1", serviceAnnotationLoadBalancerClusterVirtualIPID: "vip-1", serviceAnnotationLoadBalancerClusterForwardingRuleIDs: "rule-3"})},
+			expectedOwner: "uid-1",
+		},
+		"legacy service with duplicated annotations": {
+			service:       newService("copy", "2", readOnlyAnnotations("")),
+			others:        []*v1.Service{newService("service", "1", readOnlyAnnotations(""))},
+			expectedErr:   true,
+			expectedEvent: "Warning XelonForeignAnnotations Load balancer annotations are also used by service default/service, refusing to manage load balancer.",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			objects := []runtime.Object{test.service}
+			for _, other := range test.others {
+				objects = append(objects, other)
+			}
+			k8sClient := fake.NewClientset(objects...)
+			recorder := record.NewFakeRecorder(10)
+			c := newClients(nil)
+			c.k8s = k8sClient
+			c.recorder = recorder
+			l := &loadBalancers{client: c, RWMutex: &sync.RWMutex{}}
+
+			err := l.checkOwnership(context.Background(), test.service)
+
+			if test.expectedErr {
+				assert.ErrorIs(t, err, errLoadBalancerForeignAnnotations)
+			} else {
+				assert.NoError(t, err)
+			}
+			actual, err := k8sClient.CoreV1().Services("default").Get(context.Background(), test.service.Name, metav1.GetOptions{})
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedOwner, actual.Annotations[serviceAnnotationLoadBalancerOwner])
+			if test.expectedEvent != "" {
+				assert.Contains(t, <-recorder.Events, test.expectedEvent)
+			} else {
+				assert.Empty(t, recorder.Events)
+			}
+		})
+	}
+}
+
+func TestLoadBalancers_EnsureLoadBalancerDeleted_foreignAnnotations(t *testing.T) {
+	service := &v1.Service{ObjectMeta: metav1.ObjectMeta{
+		Name:      "copy",
+		Namespace: "default",
+		UID:       "uid-2",
+		Annotations: map[string]string{
+			serviceAnnotationLoadBalancerClusterID:                "lb-1",
+			serviceAnnotationLoadBalancerClusterVirtualIPID:       "vip-1",
+			serviceAnnotationLoadBalancerClusterForwardingRuleIDs: "rule-1",
+			serviceAnnotationLoadBalancerOwner:                    "uid-1",
+		},
+	}}
+	k8sClient := fake.NewClientset(service)
+	c := newClients(nil)
+	c.k8s = k8sClient
+	l := newLoadBalancers(c, &tenantResolver{id: "tenant-id", resolved: true}, "cloud-id", "cluster-id", loadBalancersConfig{})
+
+	// Xelon client is nil, so any call to Xelon API would panic
+	err := l.EnsureLoadBalancerDeleted(context.Background(), "cluster", service)
+
+	assert.NoError(t, err)
+	actual, err := k8sClient.CoreV1().Services("default").Get(context.Background(), "copy", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Empty(t, actual.Annotations, "copied annotations must be removed")
+}
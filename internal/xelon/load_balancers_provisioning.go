@@ -0,0 +1,90 @@
+package xelon
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// provisioningBackoff tracks services waiting for their load balancer cluster
+// to become active. Retries of each service are delayed exponentially,
+// starting with initial and capped at max, until timeout is exceeded.
+type provisioningBackoff struct {
+	initial time.Duration
+	max     time.Duration
+	timeout time.Duration
+
+	// now is replaced in tests
+	now func() time.Time
+
+	mu       sync.Mutex
+	services map[types.UID]*provisioningAttempts
+}
+
+type provisioningAttempts struct {
+	since    time.Time
+	attempts int
+
+	// timeoutReported is set once the timeout is reported for the service
+	timeoutReported bool
+}
+
+func newProvisioningBackoff(config loadBalancersConfig) *provisioningBackoff {
+	return &provisioningBackoff{
+		initial:  config.ProvisioningRetryInterval.Duration,
+		max:      config.ProvisioningMaxRetryInterval.Duration,
+		timeout:  config.ProvisioningTimeout.Duration,
+		now:      time.Now,
+		services: make(map[types.UID]*provisioningAttempts),
+	}
+}
+
+// next records an attempt of the service and returns the delay before the
+// next retry, how long the service is waiting already and whether the
+// timeout is exceeded.
+func (b *provisioningBackoff) next(uid types.UID) (time.Duration, time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	service, ok := b.services[uid]
+	if !ok {
+		service = &provisioningAttempts{since: now}
+		b.services[uid] = service
+	}
+	waiting := now.Sub(service.since)
+	if waiting >= b.timeout {
+		return 0, waiting, true
+	}
+
+	delay := b.initial
+	for i := 0; i < service.attempts && delay < b.max; i++ {
+		delay *= 2
+	}
+	service.attempts++
+
+	return min(delay, b.max), waiting, false
+}
+
+// reportTimeout returns true only on the first call for a service, which
+// exceeded the timeout, so the timeout is reported once per service.
+func (b *provisioningBackoff) reportTimeout(uid types.UID) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	service, ok := b.services[uid]
+	if !ok || service.timeoutReported {
+		return false
+	}
+	service.timeoutReported = true
+	return true
+}
+
+// reset forgets the service, e.g. once its load balancer cluster is active.
+func (b *provisioningBackoff) reset(uid types.UID) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.services, uid)
+}
@@ -0,0 +1,137 @@
+package xelon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	apierrors "k8s.io/cloud-provider/api"
+
+	"github.com/Xelon-AG/xelon-sdk-go/xelon"
+)
+
+func TestProvisioningBackoff_next(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := newProvisioningBackoff(loadBalancersConfig{
+		ProvisioningRetryInterval:    metav1.Duration{Duration: 10 * time.Second},
+		ProvisioningMaxRetryInterval: metav1.Duration{Duration: 30 * time.Second},
+		ProvisioningTimeout:          metav1.Duration{Duration: time.Minute},
+	})
+	b.now = func() time.Time { return now }
+
+	var delays []time.Duration
+	for range 4 {
+		delay, _, timedOut := b.next("uid")
+		assert.False(t, timedOut)
+		delays = append(delays, delay)
+	}
+	assert.Equal(t, []time.Duration{10 * time.Second, 20 * time.Second, 30 * time.Second, 30 * time.Second}, delays)
+
+	delay, _, timedOut := b.next("other-uid")
+	assert.False(t, timedOut)
+	assert.Equal(t, 10*time.Second, delay, "backoff is tracked per service")
+
+	now = now.Add(time.Minute)
+	_, waiting, timedOut := b.next("uid")
+	assert.True(t, timedOut)
+	assert.Equal(t, time.Minute, waiting)
+	assert.True(t, b.reportTimeout("uid"))
+	assert.False(t, b.reportTimeout("uid"), "timeout is reported once")
+
+	b.reset("uid")
+	delay, _, timedOut = b.next("uid")
+	assert.False(t, timedOut)
+	assert.Equal(t, 10*time.Second, delay)
+}
+
+func TestLoadBalancers_EnsureLoadBalancer_clusterStatus(t *testing.T) {
+	type testCase struct {
+		status        string
+		timedOut      bool
+		expectedRetry time.Duration
+		expectedEvent string
+	}
+	tests := map[string]testCase{
+		"provisioning": {
+			status:        xelonLoadBalancerClusterStatusProvisioning,
+			expectedRetry: 10 * time.Second,
+		},
+		"other transitional status": {
+			status:        "Updating",
+			expectedRetry: 10 * time.Second,
+		},
+		"final status": {
+			status: xelonLoadBalancerClusterStatusFailed,
+		},
+		"provisioning timeout": {
+			status:        xelonLoadBalancerClusterStatusProvisioning,
+			timedOut:      true,
+			expectedEvent: "Warning XelonLoadBalancerProvisioningTimeout Gave up waiting for load balancer cluster after 1m0s: load balancer cluster is not active yet (current status: Provisioning)",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /lb-clusters/lb-1", func(w http.ResponseWriter, _ *http.Request) {
+				assert.NoError(t, json.NewEncoder(w).Encode(xelon.LoadBalancerCluster{ID: "lb-1", Status: test.status}))
+			})
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			service := &v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "service",
+					Namespace:   "default",
+					UID:         "uid",
+					Annotations: map[string]string{serviceAnnotationLoadBalancerClusterID: "lb-1"},
+				},
+			}
+			recorder := record.NewFakeRecorder(10)
+			c := newClients(xelon.NewClient("token", xelon.WithBaseURL(server.URL+"/")))
+			c.k8s = fake.NewClientset(service)
+			c.recorder = recorder
+			l := newLoadBalancers(c, &tenantResolver{id: "tenant-id", resolved: true}, "cloud-id", "cluster-id", loadBalancersConfig{
+				ProvisioningRetryInterval:    metav1.Duration{Duration: 10 * time.Second},
+				ProvisioningMaxRetryInterval: metav1.Duration{Duration: time.Minute},
+				ProvisioningTimeout:          metav1.Duration{Duration: time.Minute},
+			}).(*loadBalancers)
+			if test.timedOut {
+				started := time.Now().Add(-time.Minute)
+				l.provisioning.services[service.UID] = &provisioningAttempts{since: started}
+				l.provisioning.now = func() time.Time { return started.Add(time.Minute) }
+			}
+
+			_, err := l.EnsureLoadBalancer(context.Background(), "cluster", service, nil)
+
+			assert.Error(t, err)
+			var retryErr *apierrors.RetryError
+			if test.expectedRetry > 0 {
+				assert.True(t, errors.As(err, &retryErr))
+				assert.Equal(t, test.expectedRetry, retryErr.RetryAfter())
+			} else {
+				assert.False(t, errors.As(err, &retryErr))
+			}
+			if test.expectedEvent != "" {
+				assert.Equal(t, test.expectedEvent, <-recorder.Events)
+			}
+			assert.Empty(t, recorder.Events)
+
+			if test.timedOut {
+				// the timeout event is not repeated on subsequent attempts
+				_, err = l.EnsureLoadBalancer(context.Background(), "cluster", service, nil)
+				assert.Error(t, err)
+				assert.Empty(t, recorder.Events)
+			}
+		})
+	}
+}
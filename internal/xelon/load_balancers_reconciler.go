@@ -1,7 +1,9 @@
 package xelon
 
 import (
+	"fmt"
 	"slices"
+	"strings"
 
 	"github.com/Xelon-AG/xelon-sdk-go/xelon"
 )
@@ -12,6 +14,20 @@ type ReconcileDiff struct {
 	rulesToDelete []xelon.LoadBalancerClusterForwardingRule
 }
 
+// String returns human-readable summary of the diff, e.g. for logs and events.
+func (d ReconcileDiff) String() string {
+	return fmt.Sprintf("create %s, update %s, delete %s",
+		formatForwardingRules(d.rulesToCreate),
+		formatForwardingRules(d.rulesToUpdate),
+		formatForwardingRules(d.rulesToDelete),
+	)
+}
+
+// isEmpty returns true if forwarding rules are up to date.
+func (d ReconcileDiff) isEmpty() bool {
+	return len(d.rulesToCreate) == 0 && len(d.rulesToUpdate) == 0 && len(d.rulesToDelete) == 0
+}
+
 func reconcile(currentRules []xelon.LoadBalancerClusterForwardingRule, desiredRules []xelon.LoadBalancerClusterForwardingRule) ReconcileDiff {
 	reconcileDiff := ReconcileDiff{}
 
@@ -78,3 +94,20 @@ func deleteByFrontendID(firstID string) func(string) bool {
 		return firstID == secondID
 	}
 }
+
+// formatForwardingRules formats rules as <frontend_port>-><backend_port>
+// with an optional proxy protocol suffix, e.g. [80->30080, 443->30443/pp2].
+func formatForwardingRules(rules []xelon.LoadBalancerClusterForwardingRule) string {
+	formatted := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		if rule.Frontend == nil || rule.Backend == nil {
+			continue
+		}
+		f := fmt.Sprintf("%d->%d", rule.Frontend.Port, rule.Backend.Port)
+		if rule.Backend.ProxyProtocol > 0 {
+			f += fmt.Sprintf("/pp%d", rule.Backend.ProxyProtocol)
+		}
+		formatted = append(formatted, f)
+	}
+	return "[" + strings.Join(formatted, ", ") + "]"
+}
@@ -151,3 +151,21 @@ func TestReconcile_deleteRules(t *testing.T) {
 		})
 	}
 }
+
+func TestReconcileDiff_String(t *testing.T) {
+	diff := ReconcileDiff{
+		rulesToCreate: []xelon.LoadBalancerClusterForwardingRule{{
+			Frontend: &xelon.LoadBalancerClusterForwardingRuleFrontendConfiguration{Port: 80},
+			Backend:  &xelon.LoadBalancerClusterForwardingRuleBackendConfiguration{Port: 30080},
+		}, {
+			Frontend: &xelon.LoadBalancerClusterForwardingRuleFrontendConfiguration{Port: 443},
+			Backend:  &xelon.LoadBalancerClusterForwardingRuleBackendConfiguration{Port: 30443, ProxyProtocol: 2},
+		}},
+		rulesToDelete: []xelon.LoadBalancerClusterForwardingRule{{
+			Frontend: &xelon.LoadBalancerClusterForwardingRuleFrontendConfiguration{Port: 8080, ID: "5qggn9mtbz"},
+			Backend:  &xelon.LoadBalancerClusterForwardingRuleBackendConfiguration{Port: 30808},
+		}},
+	}
+
+	assert.Equal(t, "create [80->30080, 443->30443/pp2], update [], delete [8080->30808]", diff.String())
+}
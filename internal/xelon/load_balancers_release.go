@@ -0,0 +1,58 @@
+package xelon
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// releaseLoadBalancer removes annotations written by the cloud controller
+// manager once forwarding rules of the service are deleted, so a service
+// switched back to type LoadBalancer does not reuse stale ids. Annotations
+// set by the user (e.g. proxy protocol version) are kept.
+func (l *loadBalancers) releaseLoadBalancer(ctx context.Context, service *v1.Service) error {
+	logger := configureLogger(ctx, "releaseLoadBalancer").WithValues(
+		"service", getServiceNameWithNamespace(service),
+	)
+
+	if !slices.ContainsFunc(serviceControllerAnnotations, func(key string) bool {
+		_, ok := service.Annotations[key]
+		return ok
+	}) {
+		return nil
+	}
+	clusterID := service.Annotations[serviceAnnotationLoadBalancerClusterID]
+	virtualIPID := service.Annotations[serviceAnnotationLoadBalancerClusterVirtualIPID]
+
+	if err := l.removeControllerAnnotations(ctx, service); err != nil {
+		return err
+	}
+
+	logger.Info("Released load balancer", "cluster_id", clusterID, "virtual_ip_id", virtualIPID)
+	if l.config.DryRun {
+		l.client.recordEventf(service, v1.EventTypeNormal, eventReasonLoadBalancerDryRun,
+			"Dry-run: would release load balancer cluster %s and virtual IP %s", clusterID, virtualIPID,
+		)
+		return nil
+	}
+	l.client.recordEventf(service, v1.EventTypeNormal, eventReasonLoadBalancerReleased,
+		"Released load balancer cluster %s and virtual IP %s", clusterID, virtualIPID,
+	)
+
+	return nil
+}
+
+// removeControllerAnnotations removes all annotations written by the cloud
+// controller manager from the service.
+func (l *loadBalancers) removeControllerAnnotations(ctx context.Context, service *v1.Service) error {
+	patcher := newServicePatcher(l.client.k8s, service, l.config.DryRun)
+	for _, key := range serviceControllerAnnotations {
+		delete(service.Annotations, key)
+	}
+	if err := patcher.Patch(ctx); err != nil {
+		return fmt.Errorf("failed to remove load balancer annotations: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package xelon
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/Xelon-AG/xelon-sdk-go/xelon"
+)
+
+func TestLoadBalancers_EnsureLoadBalancerDeleted_release(t *testing.T) {
+	type testCase struct {
+		annotations       map[string]string
+		clusterNotFound   bool
+		dryRun            bool
+		expected          map[string]string
+		expectedDeleted   []string
+		expectedEvents    []string
+		expectedNoRequest bool
+	}
+	tests := map[string]testCase{
+		"forwarding rules deleted": {
+			annotations: map[string]string{
+				serviceAnnotationLoadBalancerClusterID:                   "lb-1",
+				serviceAnnotationLoadBalancerClusterVirtualIPID:          "vip-1",
+				serviceAnnotationLoadBalancerClusterForwardingRuleIDs:    "rule-1",
+				serviceAnnotationLoadBalancerOwner:                       "uid",
+				serviceAnnotationLoadBalancerClusterProxyProtocolVersion: "1",
+			},
+			expected:        map[string]string{serviceAnnotationLoadBalancerClusterProxyProtocolVersion: "1"},
+			expectedDeleted: []string{"/lb-clusters/lb-1/virtual-ips/vip-1/forwarding-rules/rule-1"},
+			expectedEvents:  []string{"Normal XelonLoadBalancerReleased Released load balancer cluster lb-1 and virtual IP vip-1"},
+		},
+		"unknown and invalid annotations do not block deletion": {
+			annotations: map[string]string{
+				serviceAnnotationLoadBalancerClusterID:                   "lb-1",
+				serviceAnnotationLoadBalancerClusterVirtualIPID:          "vip-1",
+				serviceAnnotationLoadBalancerClusterForwardingRuleIDs:    "rule-1",
+				serviceAnnotationLoadBalancerOwner:                       "uid",
+				serviceAnnotationLoadBalancerClusterProxyProtocolVersion: "abc",
+				serviceAnnotationLoadBalancerClaim:                       "default/old-service",
+				"service.beta.kubernetes.io/xelon-load-balancer-unknown": "value",
+			},
+			expected: map[string]string{
+				serviceAnnotationLoadBalancerClusterProxyProtocolVersion: "abc",
+				serviceAnnotationLoadBalancerClaim:                       "default/old-service",
+				"service.beta.kubernetes.io/xelon-load-balancer-unknown": "value",
+			},
+			expectedDeleted: []string{"/lb-clusters/lb-1/virtual-ips/vip-1/forwarding-rules/rule-1"},
+			expectedEvents:  []string{"Normal XelonLoadBalancerReleased Released load balancer cluster lb-1 and virtual IP vip-1"},
+		},
+		"load balancer cluster does not exist": {
+			annotations: map[string]string{
+				serviceAnnotationLoadBalancerClusterID:                "lb-1",
+				serviceAnnotationLoadBalancerClusterVirtualIPID:       "vip-1",
+				serviceAnnotationLoadBalancerClusterForwardingRuleIDs: "rule-1",
+				serviceAnnotationLoadBalancerOwner:                    "uid",
+			},
+			clusterNotFound: true,
+			expectedEvents:  []string{"Normal XelonLoadBalancerReleased Released load balancer cluster lb-1 and virtual IP vip-1"},
+		},
+		"no load balancer annotations": {
+			annotations:       map[string]string{serviceAnnotationLoadBalancerClusterProxyProtocolVersion: "1"},
+			expected:          map[string]string{serviceAnnotationLoadBalancerClusterProxyProtocolVersion: "1"},
+			expectedNoRequest: true,
+		},
+		"dry-run": {
+			annotations: map[string]string{
+				serviceAnnotationLoadBalancerClusterID:                "lb-1",
+				serviceAnnotationLoadBalancerClusterVirtualIPID:       "vip-1",
+				serviceAnnotationLoadBalancerClusterForwardingRuleIDs: "rule-1",
+				serviceAnnotationLoadBalancerOwner:                    "uid",
+			},
+			dryRun: true,
+			expectedEvents: []string{
+				"Normal XelonLoadBalancerDryRun Dry-run: would delete forwarding rules on virtual IP vip-1",
+				"Normal XelonLoadBalancerDryRun Dry-run: would release load balancer cluster lb-1 and virtual IP vip-1",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			writeJSON := func(w http.ResponseWriter, v any) {
+				assert.NoError(t, json.NewEncoder(w).Encode(v))
+			}
+			var requested bool
+			var deleted []string
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /lb-clusters/lb-1", func(w http.ResponseWriter, _ *http.Request) {
+				requested = true
+				if test.clusterNotFound {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				writeJSON(w, xelon.LoadBalancerCluster{ID: "lb-1", Status: xelonLoadBalancerClusterStatusActive})
+			})
+			mux.HandleFunc("GET /lb-clusters/lb-1/virtual-ips/vip-1", func(w http.ResponseWriter, _ *http.Request) {
+				writeJSON(w, xelon.LoadBalancerClusterVirtualIP{ID: "vip-1", IPAddress: "10.0.0.1"})
+			})
+			mux.HandleFunc("GET /lb-clusters/lb-1/virtual-ips/vip-1/forwarding-rules", func(w http.ResponseWriter, _ *http.Request) {
+				writeJSON(w, []xelon.LoadBalancerClusterForwardingRule{
+					{Frontend: &xelon.LoadBalancerClusterForwardingRuleFrontendConfiguration{ID: "rule-1", Port: 80}},
+					{Frontend: &xelon.LoadBalancerClusterForwardingRuleFrontendConfiguration{ID: "other-rule", Port: 443}},
+				})
+			})
+			mux.HandleFunc("DELETE /lb-clusters/", func(w http.ResponseWriter, r *http.Request) {
+				deleted = append(deleted, r.URL.Path)
+				w.WriteHeader(http.StatusNoContent)
+			})
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			service := &v1.Service{ObjectMeta: metav1.ObjectMeta{
+				Name:        "service",
+				Namespace:   "default",
+				UID:         "uid",
+				Annotations: test.annotations,
+			}}
+			k8sClient := fake.NewClientset(service.DeepCopy())
+			recorder := record.NewFakeRecorder(10)
+			c := newClients(xelon.NewClient("token", xelon.WithBaseURL(server.URL+"/")))
+			c.k8s = k8sClient
+			c.recorder = recorder
+			l := newLoadBalancers(c, &tenantResolver{id: "tenant-id", resolved: true}, "cloud-id", "cluster-id", loadBalancersConfig{DryRun: test.dryRun})
+
+			err := l.EnsureLoadBalancerDeleted(context.Background(), "cluster", service)
+
+			assert.NoError(t, err)
+			if !test.dryRun {
+				// fake clientset does not support server-side dry-run, so patched annotations are checked only without it
+				actual, err := k8sClient.CoreV1().Services("default").Get(context.Background(), "service", metav1.GetOptions{})
+				assert.NoError(t, err)
+				assert.Equal(t, test.expected, actual.Annotations)
+			}
+			assert.Equal(t, test.expectedDeleted, deleted)
+			assert.Equal(t, !test.expectedNoRequest, requested)
+			for _, expectedEvent := range test.expectedEvents {
+				assert.Contains(t, <-recorder.Events, expectedEvent)
+			}
+			assert.Empty(t, recorder.Events)
+		})
+	}
+}
@@ -0,0 +1,313 @@
+package xelon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+)
+
+// retainedLoadBalancersConfigMapName is the name of the config map in the
+// namespace of the cloud controller manager recording retained load balancers,
+// keyed by <namespace>.<name> of the service. It is not stored in namespaces of
+// services, so records can not be forged by users allowed to edit config maps.
+const retainedLoadBalancersConfigMapName = "xelon-retained-load-balancers"
+
+// retainedLoadBalancer is a record of forwarding rules kept after a service
+// with retain-on-delete policy was deleted or changed to another type.
+type retainedLoadBalancer struct {
+	ClusterID         string      `json:"clusterId"`
+	VirtualIPID       string      `json:"virtualIpId"`
+	ForwardingRuleIDs []string    `json:"forwardingRuleIds,omitempty"`
+	ServiceUID        types.UID   `json:"serviceUid"`
+	RetainedAt        metav1.Time `json:"retainedAt"`
+}
+
+// retainLoadBalancer records forwarding rules of the service as retained
+// instead of deleting them, and removes controller annotations, so the rules
+// can only be re-adopted by a service claiming them. An existing record of
+// another load balancer for the same service name is never overwritten.
+func (l *loadBalancers) retainLoadBalancer(ctx context.Context, service *v1.Service, annotations *serviceAnnotations) error {
+	logger := configureLogger(ctx, "retainLoadBalancer").WithValues(
+		"service", getServiceNameWithNamespace(service),
+	)
+
+	if l.config.DryRun {
+		l.client.recordEventf(service, v1.EventTypeNormal, eventReasonLoadBalancerDryRun,
+			"Dry-run: would retain forwarding rules %s on load balancer cluster %s and virtual IP %s",
+			strings.Join(annotations.forwardingRuleIDs, ","), annotations.loadBalancerClusterID, annotations.virtualIPID,
+		)
+		return nil
+	}
+
+	record := retainedLoadBalancer{
+		ClusterID:         annotations.loadBalancerClusterID,
+		VirtualIPID:       annotations.virtualIPID,
+		ForwardingRuleIDs: annotations.forwardingRuleIDs,
+		ServiceUID:        service.UID,
+		RetainedAt:        metav1.NewTime(l.now()),
+	}
+	value, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	key := retainedLoadBalancerKey(service.Namespace, service.Name)
+	errAlreadyRetained := fmt.Errorf("another load balancer is already retained for service %s", getServiceNameWithNamespace(service))
+	err = l.updateRetainedLoadBalancers(ctx, func(data map[string]string) error {
+		if existing, ok := data[key]; ok {
+			existingRecord := retainedLoadBalancer{}
+			if err := json.Unmarshal([]byte(existing), &existingRecord); err != nil || existingRecord.ClusterID != record.ClusterID || existingRecord.VirtualIPID != record.VirtualIPID {
+				return errAlreadyRetained
+			}
+		}
+		data[key] = string(value)
+		return nil
+	})
+	if errors.Is(err, errAlreadyRetained) {
+		l.client.recordEventf(service, v1.EventTypeWarning, eventReasonLoadBalancerRetainFailed,
+			"Another load balancer is already retained for service %s, claim it or remove %s from config map %s/%s before deleting this service",
+			service.Name, key, l.client.namespace, retainedLoadBalancersConfigMapName,
+		)
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record retained load balancer: %w", err)
+	}
+
+	if err := l.removeControllerAnnotations(ctx, service); err != nil {
+		return err
+	}
+
+	logger.Info("Retained load balancer", "cluster_id", record.ClusterID, "virtual_ip_id", record.VirtualIPID, "forwarding_rule_ids", record.ForwardingRuleIDs)
+	l.client.recordEventf(service, v1.EventTypeNormal, eventReasonLoadBalancerRetained,
+		"Retained forwarding rules %s on load balancer cluster %s and virtual IP %s, claim them with annotation %s: %s",
+		strings.Join(record.ForwardingRuleIDs, ","), record.ClusterID, record.VirtualIPID, serviceAnnotationLoadBalancerClaim, service.Name,
+	)
+
+	return nil
+}
+
+// claimRetainedLoadBalancer re-adopts forwarding rules retained by the
+// service named in the claim annotation. Rules are only adopted if their load
+// balancer cluster belongs to the Kubernetes cluster and no other service
+// references them. Claimed rules are written to controller annotations first
+// and the record is removed afterwards, so retained rules are never left
+// unreferenced.
+func (l *loadBalancers) claimRetainedLoadBalancer(ctx context.Context, service *v1.Service) error {
+	logger := configureLogger(ctx, "claimRetainedLoadBalancer").WithValues(
+		"service", getServiceNameWithNamespace(service),
+	)
+
+	annotations, err := parseServiceAnnotations(service.Annotations)
+	if err != nil {
+		return fmt.Errorf("invalid service annotations: %w", err)
+	}
+	if annotations.claim == "" {
+		return nil
+	}
+
+	key := retainedLoadBalancerKey(service.Namespace, annotations.claim)
+	configMap, err := l.client.k8s.CoreV1().ConfigMaps(l.client.namespace).Get(ctx, retainedLoadBalancersConfigMapName, metav1.GetOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+	var record *retainedLoadBalancer
+	if err == nil {
+		if value, ok := configMap.Data[key]; ok {
+			record = &retainedLoadBalancer{}
+			if err := json.Unmarshal([]byte(value), record); err != nil {
+				return fmt.Errorf("invalid retained load balancer %s: %w", key, err)
+			}
+		}
+	}
+
+	if annotations.hasLoadBalancer() {
+		// the record of an already claimed load balancer is left if removing it failed before
+		if record == nil || record.ClusterID != annotations.loadBalancerClusterID || record.VirtualIPID != annotations.virtualIPID {
+			return nil
+		}
+		return l.removeRetainedLoadBalancer(ctx, key)
+	}
+	if record == nil {
+		l.client.recordEventf(service, v1.EventTypeWarning, eventReasonLoadBalancerClaimFailed,
+			"No load balancer is retained for service %s in namespace %s", annotations.claim, service.Namespace,
+		)
+		return fmt.Errorf("no load balancer is retained for service %s/%s", service.Namespace, annotations.claim)
+	}
+	if err := l.checkRetainedLoadBalancer(ctx, service, record); err != nil {
+		l.client.recordEventf(service, v1.EventTypeWarning, eventReasonLoadBalancerClaimFailed,
+			"Refusing to claim load balancer retained for service %s: %v", annotations.claim, err,
+		)
+		return fmt.Errorf("refusing to claim load balancer retained for service %s/%s: %w", service.Namespace, annotations.claim, err)
+	}
+
+	if l.config.DryRun {
+		l.client.recordEventf(service, v1.EventTypeNormal, eventReasonLoadBalancerDryRun,
+			"Dry-run: would claim retained forwarding rules %s on load balancer cluster %s and virtual IP %s",
+			strings.Join(record.ForwardingRuleIDs, ","), record.ClusterID, record.VirtualIPID,
+		)
+		return nil
+	}
+
+	patcher := newServicePatcher(l.client.k8s, service, l.config.DryRun)
+	updateServiceAnnotation(service, serviceAnnotationLoadBalancerClusterID, record.ClusterID)
+	updateServiceAnnotation(service, serviceAnnotationLoadBalancerClusterVirtualIPID, record.VirtualIPID)
+	updateServiceAnnotation(service, serviceAnnotationLoadBalancerClusterForwardingRuleIDs, strings.Join(record.ForwardingRuleIDs, ","))
+	updateServiceAnnotation(service, serviceAnnotationLoadBalancerOwner, string(service.UID))
+	if err := patcher.Patch(ctx); err != nil {
+		return err
+	}
+	if err := l.removeRetainedLoadBalancer(ctx, key); err != nil {
+		return err
+	}
+
+	logger.Info("Claimed retained load balancer", "claim", annotations.claim, "cluster_id", record.ClusterID, "virtual_ip_id", record.VirtualIPID)
+	l.client.recordEventf(service, v1.EventTypeNormal, eventReasonLoadBalancerClaimed,
+		"Claimed retained forwarding rules %s on load balancer cluster %s and virtual IP %s",
+		strings.Join(record.ForwardingRuleIDs, ","), record.ClusterID, record.VirtualIPID,
+	)
+
+	return nil
+}
+
+// checkRetainedLoadBalancer makes sure a retained load balancer can be adopted
+// by the service: the record was written for a service, its load balancer
+// cluster belongs to the Kubernetes cluster and no other service references
+// its forwarding rules.
+func (l *loadBalancers) checkRetainedLoadBalancer(ctx context.Context, service *v1.Service, record *retainedLoadBalancer) error {
+	if err := record.validate(); err != nil {
+		return err
+	}
+
+	loadBalancerCluster, err := l.fetchXelonLoadBalancerCluster(ctx, record.ClusterID)
+	if err != nil {
+		return err
+	}
+	if loadBalancerCluster.KubernetesClusterID != l.clusterID {
+		return fmt.Errorf("load balancer cluster %s does not belong to Kubernetes cluster %s", record.ClusterID, l.clusterID)
+	}
+
+	services, err := l.client.k8s.CoreV1().Services(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, other := range services.Items {
+		if other.UID == service.UID {
+			continue
+		}
+		otherAnnotations := parseControllerAnnotations(other.Annotations)
+		references := func(clusterID, virtualIPID string, ids []string) bool {
+			return clusterID == record.ClusterID && virtualIPID == record.VirtualIPID &&
+				slices.ContainsFunc(ids, func(id string) bool { return slices.Contains(record.ForwardingRuleIDs, id) })
+		}
+		if references(otherAnnotations.loadBalancerClusterID, otherAnnotations.virtualIPID, otherAnnotations.forwardingRuleIDs) ||
+			references(otherAnnotations.migrationSourceClusterID, otherAnnotations.migrationSourceVirtualIPID, otherAnnotations.migrationSourceForwardingRuleIDs) {
+			return fmt.Errorf("forwarding rules are used by service %s", getServiceNameWithNamespace(&other))
+		}
+	}
+
+	return nil
+}
+
+// validate returns an error if the record is incomplete, e.g. written by hand.
+func (r *retainedLoadBalancer) validate() error {
+	if r.ClusterID == "" || r.VirtualIPID == "" || len(r.ForwardingRuleIDs) == 0 {
+		return errors.New("record has no load balancer cluster, virtual IP or forwarding rules")
+	}
+	if _, err := uuid.Parse(string(r.ServiceUID)); err != nil {
+		return fmt.Errorf("record has invalid service UID %q", r.ServiceUID)
+	}
+	return nil
+}
+
+func (l *loadBalancers) removeRetainedLoadBalancer(ctx context.Context, key string) error {
+	err := l.updateRetainedLoadBalancers(ctx, func(data map[string]string) error {
+		delete(data, key)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove claimed load balancer %s from config map %s: %w", key, retainedLoadBalancersConfigMapName, err)
+	}
+	return nil
+}
+
+// updateRetainedLoadBalancers applies update to records of retained load
+// balancers, the config map is created if it does not exist yet.
+func (l *loadBalancers) updateRetainedLoadBalancers(ctx context.Context, update func(data map[string]string) error) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMaps := l.client.k8s.CoreV1().ConfigMaps(l.client.namespace)
+		configMap, err := configMaps.Get(ctx, retainedLoadBalancersConfigMapName, metav1.GetOptions{})
+		if k8serrors.IsNotFound(err) {
+			data := map[string]string{}
+			if err := update(data); err != nil {
+				return err
+			}
+			configMap = &v1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      retainedLoadBalancersConfigMapName,
+					Namespace: l.client.namespace,
+				},
+				Data: data,
+			}
+			_, err = configMaps.Create(ctx, configMap, metav1.CreateOptions{})
+			if k8serrors.IsAlreadyExists(err) {
+				// retried as conflict, the config map was created concurrently
+				return k8serrors.NewConflict(v1.Resource("configmaps"), retainedLoadBalancersConfigMapName, err)
+			}
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		if configMap.Data == nil {
+			configMap.Data = map[string]string{}
+		}
+		if err := update(configMap.Data); err != nil {
+			return err
+		}
+		_, err = configMaps.Update(ctx, configMap, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// retainedForwardingRules returns keys of forwarding rules recorded as
+// retained. Malformed records are skipped, so they never block garbage
+// collection of other forwarding rules.
+func retainedForwardingRules(ctx context.Context, c *clients) ([]string, error) {
+	configMap, err := c.k8s.CoreV1().ConfigMaps(c.namespace).Get(ctx, retainedLoadBalancersConfigMapName, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for name, value := range configMap.Data {
+		record := retainedLoadBalancer{}
+		if err := json.Unmarshal([]byte(value), &record); err != nil {
+			klog.ErrorS(err, "Skipping invalid retained load balancer", "config_map", c.namespace+"/"+retainedLoadBalancersConfigMapName, "key", name)
+			continue
+		}
+		for _, id := range record.ForwardingRuleIDs {
+			keys = append(keys, forwardingRuleKey(record.ClusterID, record.VirtualIPID, id))
+		}
+	}
+
+	return keys, nil
+}
+
+func retainedLoadBalancerKey(namespace, name string) string {
+	return namespace + "." + name
+}
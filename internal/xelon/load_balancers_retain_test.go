@@ -0,0 +1,256 @@
+package xelon
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/Xelon-AG/xelon-sdk-go/xelon"
+)
+
+func TestLoadBalancers_EnsureLoadBalancerDeleted_retain(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	annotations := map[string]string{
+		serviceAnnotationLoadBalancerClusterID:                "lb-1",
+		serviceAnnotationLoadBalancerClusterVirtualIPID:       "vip-1",
+		serviceAnnotationLoadBalancerClusterForwardingRuleIDs: "rule-1,rule-2",
+		serviceAnnotationLoadBalancerOwner:                    "uid",
+		serviceAnnotationLoadBalancerRetainOnDelete:           "true",
+	}
+
+	type testCase struct {
+		configMap     *v1.ConfigMap
+		expectedErr   bool
+		expected      map[string]string
+		expectedData  map[string]string
+		expectedEvent string
+	}
+	tests := map[string]testCase{
+		"record is created": {
+			expected: map[string]string{serviceAnnotationLoadBalancerRetainOnDelete: "true"},
+			expectedData: map[string]string{
+				"default.service": `{"clusterId":"lb-1","virtualIpId":"vip-1","forwardingRuleIds":["rule-1","rule-2"],"serviceUid":"uid","retainedAt":"2024-01-01T00:00:00Z"}`,
+			},
+			expectedEvent: "Normal XelonLoadBalancerRetained Retained forwarding rules rule-1,rule-2 on load balancer cluster lb-1 and virtual IP vip-1, claim them with annotation service.beta.kubernetes.io/xelon-load-balancer-claim: service",
+		},
+		"record is added": {
+			configMap: &v1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: retainedLoadBalancersConfigMapName, Namespace: metav1.NamespaceSystem},
+				Data:       map[string]string{"default.other": `{"clusterId":"lb-1","virtualIpId":"vip-2"}`},
+			},
+			expected: map[string]string{serviceAnnotationLoadBalancerRetainOnDelete: "true"},
+			expectedData: map[string]string{
+				"default.other":   `{"clusterId":"lb-1","virtualIpId":"vip-2"}`,
+				"default.service": `{"clusterId":"lb-1","virtualIpId":"vip-1","forwardingRuleIds":["rule-1","rule-2"],"serviceUid":"uid","retainedAt":"2024-01-01T00:00:00Z"}`,
+			},
+			expectedEvent: "Normal XelonLoadBalancerRetained Retained forwarding rules rule-1,rule-2 on load balancer cluster lb-1 and virtual IP vip-1",
+		},
+		"another load balancer is already retained": {
+			configMap: &v1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: retainedLoadBalancersConfigMapName, Namespace: metav1.NamespaceSystem},
+				Data:       map[string]string{"default.service": `{"clusterId":"lb-1","virtualIpId":"vip-2"}`},
+			},
+			expectedErr:   true,
+			expected:      annotations,
+			expectedData:  map[string]string{"default.service": `{"clusterId":"lb-1","virtualIpId":"vip-2"}`},
+			expectedEvent: "Warning XelonLoadBalancerRetainFailed Another load balancer is already retained for service service",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			service := &v1.Service{ObjectMeta: metav1.ObjectMeta{
+				Name:        "service",
+				Namespace:   "default",
+				UID:         "uid",
+				Annotations: mergeAnnotations(annotations, nil),
+			}}
+			objects := []runtime.Object{service.DeepCopy()}
+			if test.configMap != nil {
+				objects = append(objects, test.configMap)
+			}
+			k8sClient := fake.NewClientset(objects...)
+			recorder := record.NewFakeRecorder(10)
+			// Xelon client is nil, so any call to Xelon API would panic
+			c := newClients(nil)
+			c.k8s = k8sClient
+			c.recorder = recorder
+			l := newLoadBalancers(c, &tenantResolver{id: "tenant-id", resolved: true}, "cloud-id", "cluster-id", loadBalancersConfig{}).(*loadBalancers)
+			l.now = func() time.Time { return now }
+
+			err := l.EnsureLoadBalancerDeleted(context.Background(), "cluster", service)
+
+			if test.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			actual, err := k8sClient.CoreV1().Services("default").Get(context.Background(), "service", metav1.GetOptions{})
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, actual.Annotations)
+			configMap, err := k8sClient.CoreV1().ConfigMaps(metav1.NamespaceSystem).Get(context.Background(), retainedLoadBalancersConfigMapName, metav1.GetOptions{})
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedData, configMap.Data)
+			assert.Contains(t, <-recorder.Events, test.expectedEvent)
+		})
+	}
+}
+
+func TestLoadBalancers_claimRetainedLoadBalancer(t *testing.T) {
+	retained := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: retainedLoadBalancersConfigMapName, Namespace: metav1.NamespaceSystem},
+		Data: map[string]string{
+			"default.old-service":   `{"clusterId":"lb-1","virtualIpId":"vip-1","forwardingRuleIds":["rule-1"],"serviceUid":"7c6e1f3a-3c1b-4d7e-9a55-0f6a2c1d9e01"}`,
+			"default.other":         `{"clusterId":"lb-1","virtualIpId":"vip-2","forwardingRuleIds":["rule-2"],"serviceUid":"7c6e1f3a-3c1b-4d7e-9a55-0f6a2c1d9e02"}`,
+			"default.foreign":       `{"clusterId":"lb-3","virtualIpId":"vip-3","forwardingRuleIds":["rule-3"],"serviceUid":"7c6e1f3a-3c1b-4d7e-9a55-0f6a2c1d9e03"}`,
+			"default.forged":        `{"clusterId":"lb-1","virtualIpId":"vip-4","forwardingRuleIds":["rule-4"],"serviceUid":"forged"}`,
+			"default.used":          `{"clusterId":"lb-1","virtualIpId":"vip-5","forwardingRuleIds":["rule-5"],"serviceUid":"7c6e1f3a-3c1b-4d7e-9a55-0f6a2c1d9e05"}`,
+			"other-namespace.moved": `{"clusterId":"lb-1","virtualIpId":"vip-6","forwardingRuleIds":["rule-6"],"serviceUid":"7c6e1f3a-3c1b-4d7e-9a55-0f6a2c1d9e06"}`,
+		},
+	}
+
+	type testCase struct {
+		annotations   map[string]string
+		expectedErr   bool
+		expected      map[string]string
+		expectedData  map[string]string
+		expectedEvent string
+	}
+	withoutRecord := func(key string) map[string]string {
+		data := maps.Clone(retained.Data)
+		delete(data, key)
+		return data
+	}
+	tests := map[string]testCase{
+		"no claim": {
+			expectedData: retained.Data,
+		},
+		"claimed": {
+			annotations: map[string]string{serviceAnnotationLoadBalancerClaim: "old-service"},
+			expected: map[string]string{
+				serviceAnnotationLoadBalancerClaim:                    "old-service",
+				serviceAnnotationLoadBalancerClusterID:                "lb-1",
+				serviceAnnotationLoadBalancerClusterVirtualIPID:       "vip-1",
+				serviceAnnotationLoadBalancerClusterForwardingRuleIDs: "rule-1",
+				serviceAnnotationLoadBalancerOwner:                    "uid",
+			},
+			expectedData:  withoutRecord("default.old-service"),
+			expectedEvent: "Normal XelonLoadBalancerClaimed Claimed retained forwarding rules rule-1 on load balancer cluster lb-1 and virtual IP vip-1",
+		},
+		"not retained": {
+			annotations:   map[string]string{serviceAnnotationLoadBalancerClaim: "unknown"},
+			expectedErr:   true,
+			expected:      map[string]string{serviceAnnotationLoadBalancerClaim: "unknown"},
+			expectedData:  retained.Data,
+			expectedEvent: "Warning XelonLoadBalancerClaimFailed No load balancer is retained for service unknown in namespace default",
+		},
+		"retained in another namespace": {
+			annotations:   map[string]string{serviceAnnotationLoadBalancerClaim: "moved"},
+			expectedErr:   true,
+			expected:      map[string]string{serviceAnnotationLoadBalancerClaim: "moved"},
+			expectedData:  retained.Data,
+			expectedEvent: "Warning XelonLoadBalancerClaimFailed No load balancer is retained for service moved in namespace default",
+		},
+		"load balancer cluster of another kubernetes cluster": {
+			annotations:   map[string]string{serviceAnnotationLoadBalancerClaim: "foreign"},
+			expectedErr:   true,
+			expected:      map[string]string{serviceAnnotationLoadBalancerClaim: "foreign"},
+			expectedData:  retained.Data,
+			expectedEvent: "Warning XelonLoadBalancerClaimFailed Refusing to claim load balancer retained for service foreign: load balancer cluster lb-3 does not belong to Kubernetes cluster cluster-id",
+		},
+		"invalid service uid": {
+			annotations:   map[string]string{serviceAnnotationLoadBalancerClaim: "forged"},
+			expectedErr:   true,
+			expected:      map[string]string{serviceAnnotationLoadBalancerClaim: "forged"},
+			expectedData:  retained.Data,
+			expectedEvent: `Warning XelonLoadBalancerClaimFailed Refusing to claim load balancer retained for service forged: record has invalid service UID "forged"`,
+		},
+		"forwarding rules used by another service": {
+			annotations:   map[string]string{serviceAnnotationLoadBalancerClaim: "used"},
+			expectedErr:   true,
+			expected:      map[string]string{serviceAnnotationLoadBalancerClaim: "used"},
+			expectedData:  retained.Data,
+			expectedEvent: "Warning XelonLoadBalancerClaimFailed Refusing to claim load balancer retained for service used: forwarding rules are used by service default/running",
+		},
+		"already claimed": {
+			annotations: map[string]string{
+				serviceAnnotationLoadBalancerClaim:              "old-service",
+				serviceAnnotationLoadBalancerClusterID:          "lb-1",
+				serviceAnnotationLoadBalancerClusterVirtualIPID: "vip-1",
+			},
+			expected: map[string]string{
+				serviceAnnotationLoadBalancerClaim:              "old-service",
+				serviceAnnotationLoadBalancerClusterID:          "lb-1",
+				serviceAnnotationLoadBalancerClusterVirtualIPID: "vip-1",
+			},
+			expectedData: withoutRecord("default.old-service"),
+		},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /lb-clusters/lb-1", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"id":"lb-1","kubernetesClusterId":"cluster-id"}`))
+	})
+	mux.HandleFunc("GET /lb-clusters/lb-3", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"id":"lb-3","kubernetesClusterId":"other-cluster-id"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			service := &v1.Service{ObjectMeta: metav1.ObjectMeta{
+				Name:        "service",
+				Namespace:   "default",
+				UID:         "uid",
+				Annotations: test.annotations,
+			}}
+			running := &v1.Service{ObjectMeta: metav1.ObjectMeta{
+				Name:      "running",
+				Namespace: "default",
+				UID:       "running-uid",
+				Annotations: map[string]string{
+					serviceAnnotationLoadBalancerClusterID:                "lb-1",
+					serviceAnnotationLoadBalancerClusterVirtualIPID:       "vip-5",
+					serviceAnnotationLoadBalancerClusterForwardingRuleIDs: "rule-5",
+				},
+			}}
+			k8sClient := fake.NewClientset(service.DeepCopy(), running, retained.DeepCopy())
+			recorder := record.NewFakeRecorder(10)
+			c := newClients(xelon.NewClient("token", xelon.WithBaseURL(server.URL+"/")))
+			c.k8s = k8sClient
+			c.recorder = recorder
+			l := &loadBalancers{client: c, clusterID: "cluster-id", RWMutex: &sync.RWMutex{}}
+
+			err := l.claimRetainedLoadBalancer(context.Background(), service)
+
+			if test.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			actual, err := k8sClient.CoreV1().Services("default").Get(context.Background(), "service", metav1.GetOptions{})
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, actual.Annotations)
+			configMap, err := k8sClient.CoreV1().ConfigMaps(metav1.NamespaceSystem).Get(context.Background(), retainedLoadBalancersConfigMapName, metav1.GetOptions{})
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedData, configMap.Data)
+			if test.expectedEvent != "" {
+				assert.Equal(t, test.expectedEvent, <-recorder.Events)
+			} else {
+				assert.Empty(t, recorder.Events)
+			}
+		})
+	}
+}
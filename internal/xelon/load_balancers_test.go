@@ -2,11 +2,16 @@ package xelon
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
 
 	"github.com/Xelon-AG/xelon-sdk-go/xelon"
 )
@@ -63,24 +68,15 @@ func TestLoadBalancers_buildLoadBalancerStatusIngress(t *testing.T) {
 				IPMode: &proxyIPMode,
 			}},
 		},
-		"invalid proxy protocol": {
-			inputLB: &xelonLoadBalancer{},
-			inputSVC: &v1.Service{
-				ObjectMeta: metav1.ObjectMeta{
-					Annotations: map[string]string{"service.beta.kubernetes.io/xelon-load-balancer-cluster-proxy-protocol-version": "invalid"},
-				},
-				Spec: v1.ServiceSpec{},
-			},
-			expected: []v1.LoadBalancerIngress{{
-				IPMode: &vipIPMode,
-			}},
-		},
 	}
 
 	l := &loadBalancers{}
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
-			actual := l.buildLoadBalancerStatusIngress(context.TODO(), test.inputLB, test.inputSVC)
+			annotations, err := parseServiceAnnotations(test.inputSVC.Annotations)
+			assert.NoError(t, err)
+
+			actual := l.buildLoadBalancerStatusIngress(context.TODO(), test.inputLB, test.inputSVC, annotations)
 			assert.Equal(t, test.expected, actual)
 		})
 	}
@@ -159,3 +155,156 @@ func TestIsVirtualIPAvailable_frontedPortAvailable(t *testing.T) {
 
 	assert.Equal(t, true, available)
 }
+
+func TestLoadBalancers_updateLoadBalancer_dryRun(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("unexpected request in dry-run mode: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte("[]"))
+	}))
+	defer server.Close()
+
+	service := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "service", Namespace: "default"},
+		Spec: v1.ServiceSpec{Ports: []v1.ServicePort{
+			{Port: 80, NodePort: 30080},
+		}},
+	}
+	k8sClient := fake.NewClientset(service)
+	recorder := record.NewFakeRecorder(10)
+	c := newClients(xelon.NewClient("token", xelon.WithBaseURL(server.URL+"/")))
+	c.k8s = k8sClient
+	c.recorder = recorder
+	l := &loadBalancers{
+		client:  c,
+		config:  loadBalancersConfig{DryRun: true},
+		RWMutex: &sync.RWMutex{},
+	}
+
+	xlb := &xelonLoadBalancer{clusterID: "cluster-id", virtualIPID: "vip-id", virtualIPAddress: "192.0.2.10"}
+
+	err := l.updateLoadBalancer(context.Background(), xlb, service)
+
+	assert.NoError(t, err)
+	assert.NotContains(t, service.Annotations, serviceAnnotationLoadBalancerClusterForwardingRuleIDs)
+	for _, action := range k8sClient.Actions() {
+		assert.NotEqual(t, "patch", action.GetVerb())
+	}
+	assert.Equal(t, "Normal XelonLoadBalancerDryRun Dry-run: would use load balancer cluster cluster-id and virtual IP vip-id (192.0.2.10) and apply forwarding rules: create [80->30080], update [], delete []", <-recorder.Events)
+
+	// unchanged plan is not recorded again
+	assert.NoError(t, l.updateLoadBalancer(context.Background(), xlb, service))
+	assert.Empty(t, recorder.Events)
+}
+
+func TestLoadBalancers_updateLoadBalancer_dryRunWithoutChanges(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`[{"frontend":{"id":"rule-1","port":80},"backend":{"id":"backend-1","port":30080}}]`))
+	}))
+	defer server.Close()
+
+	service := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "service", Namespace: "default", Annotations: map[string]string{
+			serviceAnnotationLoadBalancerClusterID:                "cluster-id",
+			serviceAnnotationLoadBalancerClusterVirtualIPID:       "vip-id",
+			serviceAnnotationLoadBalancerClusterForwardingRuleIDs: "rule-1",
+		}},
+		Spec: v1.ServiceSpec{Ports: []v1.ServicePort{
+			{Port: 80, NodePort: 30080},
+		}},
+	}
+	recorder := record.NewFakeRecorder(10)
+	c := newClients(xelon.NewClient("token", xelon.WithBaseURL(server.URL+"/")))
+	c.k8s = fake.NewClientset(service)
+	c.recorder = recorder
+	l := &loadBalancers{
+		client:  c,
+		config:  loadBalancersConfig{DryRun: true},
+		RWMutex: &sync.RWMutex{},
+	}
+
+	err := l.updateLoadBalancer(context.Background(), &xelonLoadBalancer{clusterID: "cluster-id", virtualIPID: "vip-id"}, service)
+
+	assert.NoError(t, err)
+	assert.Empty(t, recorder.Events)
+}
+
+func TestLoadBalancers_updateLoadBalancer_recordCreatedForwardingRules(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /lb-clusters/lb-1/virtual-ips/vip-1/forwarding-rules", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("[]"))
+	})
+	mux.HandleFunc("POST /lb-clusters/lb-1/virtual-ips/vip-1/forwarding-rules", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`[{"frontend":{"id":"rule-1","port":80}}]`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	service := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "service", Namespace: "default"},
+		Spec: v1.ServiceSpec{Ports: []v1.ServicePort{
+			{Port: 80, NodePort: 30080},
+		}},
+	}
+	k8sClient := fake.NewClientset(service)
+	c := newClients(xelon.NewClient("token", xelon.WithBaseURL(server.URL+"/")))
+	c.k8s = k8sClient
+	l := &loadBalancers{
+		client:  c,
+		RWMutex: &sync.RWMutex{},
+	}
+
+	err := l.updateLoadBalancer(context.Background(), &xelonLoadBalancer{clusterID: "lb-1", virtualIPID: "vip-1"}, service)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "rule-1", service.Annotations[serviceAnnotationLoadBalancerClusterForwardingRuleIDs])
+	created, err := createdForwardingRules(context.Background(), c)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"lb-1/vip-1/rule-1"}, created.UnsortedList())
+}
+
+func TestLoadBalancers_retrieveXelonLoadBalancer_dryRun(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /lb-clusters/lb-1", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"id":"lb-1","status":"` + xelonLoadBalancerClusterStatusActive + `"}`))
+	})
+	mux.HandleFunc("GET /lb-clusters/lb-1/virtual-ips/vip-1", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"id":"vip-1","ipAddress":"10.0.0.1"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	annotations := map[string]string{
+		serviceAnnotationLoadBalancerClusterID:          "lb-1",
+		serviceAnnotationLoadBalancerClusterVirtualIPID: "vip-1",
+	}
+	service := &v1.Service{ObjectMeta: metav1.ObjectMeta{
+		Name:        "service",
+		Namespace:   "default",
+		UID:         "uid",
+		Annotations: mergeAnnotations(annotations, nil),
+	}}
+	k8sClient := fake.NewClientset(service.DeepCopy())
+	recorder := record.NewFakeRecorder(10)
+	c := newClients(xelon.NewClient("token", xelon.WithBaseURL(server.URL+"/")))
+	c.k8s = k8sClient
+	c.recorder = recorder
+	l := &loadBalancers{
+		client:  c,
+		config:  loadBalancersConfig{DryRun: true},
+		RWMutex: &sync.RWMutex{},
+	}
+
+	xlb, err := l.retrieveXelonLoadBalancer(context.Background(), service)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &xelonLoadBalancer{clusterID: "lb-1", virtualIPID: "vip-1", virtualIPAddress: "10.0.0.1"}, xlb)
+	assert.Equal(t, annotations, service.Annotations)
+	for _, action := range k8sClient.Actions() {
+		assert.NotEqual(t, "patch", action.GetVerb())
+	}
+	assert.Empty(t, recorder.Events)
+}
@@ -0,0 +1,83 @@
+package xelon
+
+import (
+	"sync"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const metricsSubsystem = "xelon"
+
+var (
+	apiUp = metrics.NewGauge(&metrics.GaugeOpts{
+		Subsystem:      metricsSubsystem,
+		Name:           "api_up",
+		Help:           "Whether the last Xelon API health check succeeded (1) or failed (0).",
+		StabilityLevel: metrics.ALPHA,
+	})
+	apiHealthChecksTotal = metrics.NewCounterVec(&metrics.CounterOpts{
+		Subsystem:      metricsSubsystem,
+		Name:           "api_health_checks_total",
+		Help:           "Number of Xelon API health checks partitioned by result.",
+		StabilityLevel: metrics.ALPHA,
+	}, []string{"result"})
+	instancesCacheAgeSeconds = metrics.NewGauge(&metrics.GaugeOpts{
+		Subsystem:      metricsSubsystem,
+		Name:           "instances_cache_age_seconds",
+		Help:           "Age of cached Xelon nodes at the last refresh attempt in seconds.",
+		StabilityLevel: metrics.ALPHA,
+	})
+	instancesCacheRefreshFailuresTotal = metrics.NewCounter(&metrics.CounterOpts{
+		Subsystem:      metricsSubsystem,
+		Name:           "instances_cache_refresh_failures_total",
+		Help:           "Number of failed refreshes of cached Xelon nodes.",
+		StabilityLevel: metrics.ALPHA,
+	})
+	inventoryNodes = metrics.NewGaugeVec(&metrics.GaugeOpts{
+		Subsystem:      metricsSubsystem,
+		Name:           "inventory_nodes",
+		Help:           "Number of Xelon nodes of the Kubernetes cluster partitioned by role and node pool.",
+		StabilityLevel: metrics.ALPHA,
+	}, []string{"role", "node_pool"})
+	inventoryLoadBalancerClusters = metrics.NewGaugeVec(&metrics.GaugeOpts{
+		Subsystem:      metricsSubsystem,
+		Name:           "inventory_load_balancer_clusters",
+		Help:           "Number of Xelon load balancer clusters of the Kubernetes cluster partitioned by status.",
+		StabilityLevel: metrics.ALPHA,
+	}, []string{"status"})
+	inventoryVirtualIPs = metrics.NewGaugeVec(&metrics.GaugeOpts{
+		Subsystem:      metricsSubsystem,
+		Name:           "inventory_virtual_ips",
+		Help:           "Number of virtual IPs of active Xelon load balancer clusters partitioned by state.",
+		StabilityLevel: metrics.ALPHA,
+	}, []string{"state"})
+	inventoryForwardingRules = metrics.NewGauge(&metrics.GaugeOpts{
+		Subsystem:      metricsSubsystem,
+		Name:           "inventory_forwarding_rules",
+		Help:           "Number of forwarding rules on virtual IPs of active Xelon load balancer clusters.",
+		StabilityLevel: metrics.ALPHA,
+	})
+	loadBalancerGCDeletedForwardingRulesTotal = metrics.NewCounter(&metrics.CounterOpts{
+		Subsystem:      metricsSubsystem,
+		Name:           "lb_gc_deleted_forwarding_rules_total",
+		Help:           "Number of orphaned forwarding rules deleted by xelon-lb-gc controller.",
+		StabilityLevel: metrics.ALPHA,
+	})
+
+	registerMetricsOnce sync.Once
+)
+
+func registerMetrics() {
+	registerMetricsOnce.Do(func() {
+		legacyregistry.MustRegister(apiUp)
+		legacyregistry.MustRegister(apiHealthChecksTotal)
+		legacyregistry.MustRegister(instancesCacheAgeSeconds)
+		legacyregistry.MustRegister(instancesCacheRefreshFailuresTotal)
+		legacyregistry.MustRegister(inventoryNodes)
+		legacyregistry.MustRegister(inventoryLoadBalancerClusters)
+		legacyregistry.MustRegister(inventoryVirtualIPs)
+		legacyregistry.MustRegister(inventoryForwardingRules)
+		legacyregistry.MustRegister(loadBalancerGCDeletedForwardingRulesTotal)
+	})
+}
@@ -0,0 +1,248 @@
+package xelon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+)
+
+var errNoPodCIDRAvailable = errors.New("no pod CIDR available in reserved ranges")
+
+// nodeIPAMSyncKey is the only key of nodeIPAM queue, as all nodes without pod
+// CIDR are allocated at once.
+const nodeIPAMSyncKey = "sync"
+
+// nodeIPAM assigns pod CIDRs to nodes from ranges reserved for the Kubernetes
+// cluster on Xelon private network. Ranges reserved for other clusters on the
+// same network are never used. Nodes are watched, so new nodes get a pod CIDR
+// immediately, and all nodes are additionally checked every interval.
+type nodeIPAM struct {
+	clients          *clients
+	tenant           *tenantResolver
+	clusterID        string
+	networkID        string
+	nodeCIDRMaskSize int
+	interval         time.Duration
+
+	queue       workqueue.TypedRateLimitingInterface[string]
+	nodesSynced cache.InformerSynced
+}
+
+func newNodeIPAM(clients *clients, tenant *tenantResolver, clusterID string, config nodeIPAMConfig) *nodeIPAM {
+	return &nodeIPAM{
+		clients:          clients,
+		tenant:           tenant,
+		clusterID:        clusterID,
+		networkID:        config.NetworkID,
+		nodeCIDRMaskSize: config.NodeCIDRMaskSize,
+		interval:         config.SyncInterval.Duration,
+		queue: workqueue.NewTypedRateLimitingQueueWithConfig(
+			workqueue.DefaultTypedControllerRateLimiter[string](),
+			workqueue.TypedRateLimitingQueueConfig[string]{Name: NodeIPAMControllerName},
+		),
+	}
+}
+
+// watchNodes queues a sync whenever a node without pod CIDR is added or updated.
+// It has to be called before the informer is started.
+func (n *nodeIPAM) watchNodes(nodeInformer coreinformers.NodeInformer) error {
+	enqueue := func(obj any) {
+		if node, ok := obj.(*v1.Node); ok && node.Spec.PodCIDR == "" && len(node.Spec.PodCIDRs) == 0 {
+			n.queue.Add(nodeIPAMSyncKey)
+		}
+	}
+	_, err := nodeInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    enqueue,
+		UpdateFunc: func(_, obj any) { enqueue(obj) },
+	})
+	n.nodesSynced = nodeInformer.Informer().HasSynced
+	return err
+}
+
+func (n *nodeIPAM) run(ctx context.Context) {
+	defer n.queue.ShutDown()
+
+	klog.InfoS("Allocating pod CIDRs from Xelon network", "network_id", n.networkID, "node_cidr_mask_size", n.nodeCIDRMaskSize, "interval", n.interval)
+	if !cache.WaitForNamedCacheSyncWithContext(ctx, n.nodesSynced) {
+		return
+	}
+
+	// reserved ranges of the network may change, so nodes are checked periodically as well
+	go wait.UntilWithContext(ctx, func(context.Context) { n.queue.Add(nodeIPAMSyncKey) }, n.interval)
+	go func() {
+		<-ctx.Done()
+		n.queue.ShutDown()
+	}()
+
+	for n.processNextSync(ctx) {
+	}
+}
+
+func (n *nodeIPAM) processNextSync(ctx context.Context) bool {
+	key, quit := n.queue.Get()
+	if quit {
+		return false
+	}
+	defer n.queue.Done(key)
+
+	if err := n.sync(ctx); err != nil {
+		klog.ErrorS(err, "Failed to allocate pod CIDRs from Xelon network", "network_id", n.networkID)
+		n.queue.AddRateLimited(key)
+		return true
+	}
+	n.queue.Forget(key)
+	return true
+}
+
+func (n *nodeIPAM) sync(ctx context.Context) error {
+	tenantID, err := n.tenant.tenantID(ctx)
+	if err != nil {
+		return err
+	}
+	network, err := getXelonNetwork(ctx, n.clients.xelon(), tenantID, n.networkID)
+	if err != nil {
+		return err
+	}
+	clusterRanges, otherRanges, err := n.reservedRanges(network)
+	if err != nil {
+		return err
+	}
+
+	nodes, err := n.clients.k8s.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	allocator := newPodCIDRAllocator(clusterRanges, n.nodeCIDRMaskSize)
+	for _, otherRange := range otherRanges {
+		// parts of cluster ranges overlapping with ranges of other clusters are never used
+		allocator.occupy(otherRange)
+	}
+	for _, node := range nodes.Items {
+		for _, podCIDR := range append([]string{node.Spec.PodCIDR}, node.Spec.PodCIDRs...) {
+			if _, cidr, err := net.ParseCIDR(podCIDR); err == nil {
+				allocator.occupy(cidr)
+			}
+		}
+	}
+
+	for _, node := range nodes.Items {
+		if node.Spec.PodCIDR != "" || len(node.Spec.PodCIDRs) > 0 {
+			continue
+		}
+		podCIDR, err := allocator.allocate()
+		if err != nil {
+			n.clients.recordEventf(&node, v1.EventTypeWarning, eventReasonPodCIDRNotAvailable,
+				"Failed to allocate pod CIDR from Xelon network %s: %v", n.networkID, err)
+			return err
+		}
+		if err := n.assignPodCIDR(ctx, node.Name, podCIDR.String()); err != nil {
+			klog.ErrorS(err, "Failed to assign pod CIDR", "node", node.Name, "pod_cidr", podCIDR)
+			continue
+		}
+		klog.InfoS("Assigned pod CIDR", "node", node.Name, "pod_cidr", podCIDR)
+	}
+
+	return nil
+}
+
+// reservedRanges returns ranges reserved for the cluster and ranges reserved
+// for other clusters on the same network.
+func (n *nodeIPAM) reservedRanges(network *xelonNetwork) ([]*net.IPNet, []*net.IPNet, error) {
+	var clusterRanges, otherRanges []*net.IPNet
+	for _, reservedRange := range network.ReservedRanges {
+		_, cidr, err := net.ParseCIDR(reservedRange.CIDR)
+		if err != nil || cidr.IP.To4() == nil {
+			klog.InfoS("Skip invalid or non IPv4 reserved range", "network_id", network.ID, "cidr", reservedRange.CIDR)
+			continue
+		}
+		if reservedRange.KubernetesClusterID == n.clusterID {
+			clusterRanges = append(clusterRanges, cidr)
+		} else {
+			otherRanges = append(otherRanges, cidr)
+		}
+	}
+	if len(clusterRanges) == 0 {
+		return nil, nil, fmt.Errorf("no IPv4 range is reserved for kubernetes cluster %s on network %s", n.clusterID, network.ID)
+	}
+
+	return clusterRanges, otherRanges, nil
+}
+
+func (n *nodeIPAM) assignPodCIDR(ctx context.Context, nodeName, podCIDR string) error {
+	patch, err := json.Marshal(map[string]any{
+		"spec": map[string]any{
+			"podCIDR":  podCIDR,
+			"podCIDRs": []string{podCIDR},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = n.clients.k8s.CoreV1().Nodes().Patch(ctx, nodeName, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// podCIDRAllocator allocates IPv4 node CIDRs of the given mask size from
+// ranges, skipping CIDRs overlapping with occupied ones.
+type podCIDRAllocator struct {
+	ranges   []*net.IPNet
+	maskSize int
+	occupied []*net.IPNet
+}
+
+func newPodCIDRAllocator(ranges []*net.IPNet, maskSize int) *podCIDRAllocator {
+	return &podCIDRAllocator{ranges: ranges, maskSize: maskSize}
+}
+
+func (a *podCIDRAllocator) occupy(cidr *net.IPNet) {
+	a.occupied = append(a.occupied, cidr)
+}
+
+func (a *podCIDRAllocator) allocate() (*net.IPNet, error) {
+	for _, r := range a.ranges {
+		rangeMaskSize, _ := r.Mask.Size()
+		if rangeMaskSize > a.maskSize {
+			continue
+		}
+		mask := net.CIDRMask(a.maskSize, 32)
+		count := 1 << (a.maskSize - rangeMaskSize)
+		base := new(big.Int).SetBytes(r.IP.To4())
+		step := new(big.Int).Lsh(big.NewInt(1), uint(32-a.maskSize))
+		for i := 0; i < count; i++ {
+			ip := new(big.Int).Add(base, new(big.Int).Mul(step, big.NewInt(int64(i))))
+			candidate := &net.IPNet{IP: net.IP(ip.FillBytes(make([]byte, 4))), Mask: mask}
+			if !a.isOccupied(candidate) {
+				a.occupy(candidate)
+				return candidate, nil
+			}
+		}
+	}
+
+	return nil, errNoPodCIDRAvailable
+}
+
+func (a *podCIDRAllocator) isOccupied(cidr *net.IPNet) bool {
+	for _, occupied := range a.occupied {
+		if cidrsOverlap(cidr, occupied) {
+			return true
+		}
+	}
+	return false
+}
+
+func cidrsOverlap(first, second *net.IPNet) bool {
+	return first.Contains(second.IP) || second.Contains(first.IP)
+}
@@ -0,0 +1,157 @@
+package xelon
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/Xelon-AG/xelon-sdk-go/xelon"
+)
+
+func TestPodCIDRAllocator_allocate(t *testing.T) {
+	type testCase struct {
+		ranges      []string
+		maskSize    int
+		occupied    []string
+		expected    string
+		expectedErr error
+	}
+	tests := map[string]testCase{
+		"first cidr": {
+			ranges:   []string{"10.244.0.0/16"},
+			maskSize: 24,
+			expected: "10.244.0.0/24",
+		},
+		"skip occupied cidrs": {
+			ranges:   []string{"10.244.0.0/16"},
+			maskSize: 24,
+			occupied: []string{"10.244.0.0/24", "10.244.1.0/25"},
+			expected: "10.244.2.0/24",
+		},
+		"next range": {
+			ranges:   []string{"10.244.0.0/24", "10.245.0.0/16"},
+			maskSize: 24,
+			occupied: []string{"10.244.0.0/24"},
+			expected: "10.245.0.0/24",
+		},
+		"range smaller than mask size": {
+			ranges:      []string{"10.244.0.0/26"},
+			maskSize:    24,
+			expectedErr: errNoPodCIDRAvailable,
+		},
+		"exhausted": {
+			ranges:      []string{"10.244.0.0/23"},
+			maskSize:    24,
+			occupied:    []string{"10.244.0.0/24", "10.244.1.0/24"},
+			expectedErr: errNoPodCIDRAvailable,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			allocator := newPodCIDRAllocator(parseCIDRs(t, test.ranges), test.maskSize)
+			for _, cidr := range parseCIDRs(t, test.occupied) {
+				allocator.occupy(cidr)
+			}
+
+			actual, err := allocator.allocate()
+
+			assert.ErrorIs(t, err, test.expectedErr)
+			if test.expected != "" {
+				assert.Equal(t, test.expected, actual.String())
+			}
+		})
+	}
+}
+
+func TestNodeIPAM_sync(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /tenant-id/networks/network-id", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"id":"network-id","reservedRanges":[
+			{"cidr":"10.244.0.0/22","kubernetesClusterId":"cluster-id"},
+			{"cidr":"10.245.0.0/16","kubernetesClusterId":"cluster-id"},
+			{"cidr":"10.244.2.0/23","kubernetesClusterId":"other-cluster-id"}
+		]}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	k8sClient := fake.NewClientset(
+		&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-1"}, Spec: v1.NodeSpec{PodCIDR: "10.244.0.0/24", PodCIDRs: []string{"10.244.0.0/24"}}},
+		&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-2"}},
+		&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-3"}, Spec: v1.NodeSpec{PodCIDR: "10.244.1.0/24"}},
+	)
+	c := newClients(xelon.NewClient("token", xelon.WithBaseURL(server.URL+"/")))
+	c.k8s = k8sClient
+	n := newNodeIPAM(c, &tenantResolver{id: "tenant-id", resolved: true}, "cluster-id", nodeIPAMConfig{NetworkID: "network-id", NodeCIDRMaskSize: 24})
+
+	err := n.sync(context.Background())
+
+	assert.NoError(t, err)
+	node, err := k8sClient.CoreV1().Nodes().Get(context.Background(), "worker-2", metav1.GetOptions{})
+	assert.NoError(t, err)
+	// pod CIDRs of other nodes and the range of the other cluster are skipped
+	assert.Equal(t, "10.245.0.0/24", node.Spec.PodCIDR)
+	assert.Equal(t, []string{"10.245.0.0/24"}, node.Spec.PodCIDRs)
+
+	clusterRanges, otherRanges, err := n.reservedRanges(&xelonNetwork{ReservedRanges: []xelonNetworkReservedRange{
+		{CIDR: "10.245.128.0/17", KubernetesClusterID: "other-cluster-id"},
+	}})
+	assert.Error(t, err)
+	assert.Nil(t, clusterRanges)
+	assert.Nil(t, otherRanges)
+}
+
+func TestNodeIPAM_run(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /tenant-id/networks/network-id", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"id":"network-id","reservedRanges":[{"cidr":"10.244.0.0/16","kubernetesClusterId":"cluster-id"}]}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	k8sClient := fake.NewClientset()
+	c := newClients(xelon.NewClient("token", xelon.WithBaseURL(server.URL+"/")))
+	c.k8s = k8sClient
+	n := newNodeIPAM(c, &tenantResolver{id: "tenant-id", resolved: true}, "cluster-id", nodeIPAMConfig{
+		NetworkID:        "network-id",
+		NodeCIDRMaskSize: 24,
+		SyncInterval:     metav1.Duration{Duration: time.Hour},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	informerFactory := informers.NewSharedInformerFactory(k8sClient, 0)
+	assert.NoError(t, n.watchNodes(informerFactory.Core().V1().Nodes()))
+	informerFactory.Start(ctx.Done())
+	go n.run(ctx)
+
+	// the node is allocated long before the next periodic check
+	_, err := k8sClient.CoreV1().Nodes().Create(ctx, &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-1"}}, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		node, err := k8sClient.CoreV1().Nodes().Get(ctx, "worker-1", metav1.GetOptions{})
+		return err == nil && node.Spec.PodCIDR == "10.244.0.0/24"
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func parseCIDRs(t *testing.T, cidrs []string) []*net.IPNet {
+	t.Helper()
+
+	var parsed []*net.IPNet
+	for _, cidr := range cidrs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		assert.NoError(t, err)
+		parsed = append(parsed, ipNet)
+	}
+	return parsed
+}
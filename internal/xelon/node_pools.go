@@ -0,0 +1,237 @@
+package xelon
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// nodeAnnotationNodePoolLabels keeps comma separated keys of labels applied
+	// from Xelon node pool, so removed labels can be cleaned up.
+	nodeAnnotationNodePoolLabels = "kubernetes.xelon.ch/node-pool-labels"
+
+	// nodeAnnotationNodePoolTaints keeps comma separated <key>:<effect> pairs of
+	// taints applied from Xelon node pool, so removed taints can be cleaned up.
+	nodeAnnotationNodePoolTaints = "kubernetes.xelon.ch/node-pool-taints"
+)
+
+// nodePoolSyncer periodically applies labels and taints defined on Xelon node
+// pools to Kubernetes nodes of these pools.
+type nodePoolSyncer struct {
+	clients   *clients
+	clusterID string
+	interval  time.Duration
+}
+
+func newNodePoolSyncer(clients *clients, clusterID string, interval time.Duration) *nodePoolSyncer {
+	return &nodePoolSyncer{
+		clients:   clients,
+		clusterID: clusterID,
+		interval:  interval,
+	}
+}
+
+func (s *nodePoolSyncer) run(ctx context.Context) {
+	if s.clusterID == "" {
+		klog.InfoS("Kubernetes cluster id is not configured, skip syncing labels and taints of Xelon node pools")
+		return
+	}
+	klog.InfoS("Syncing labels and taints of Xelon node pools", "cluster_id", s.clusterID, "interval", s.interval)
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := s.sync(ctx); err != nil {
+			klog.ErrorS(err, "Failed to sync labels and taints of Xelon node pools")
+		}
+	}, s.interval)
+}
+
+func (s *nodePoolSyncer) sync(ctx context.Context) error {
+	nodePools, err := listXelonNodePools(ctx, s.clients.xelon(), s.clusterID)
+	if err != nil {
+		return err
+	}
+
+	nodes, err := s.clients.k8s.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	nodesByLocalVMID := make(map[string]string, len(nodes.Items))
+	nodesByName := make(map[string]string, len(nodes.Items))
+	for _, node := range nodes.Items {
+		nodesByName[node.Name] = node.Name
+		if localVMID, err := parseProviderID(node.Spec.ProviderID); err == nil {
+			nodesByLocalVMID[localVMID] = node.Name
+		}
+	}
+
+	for _, nodePool := range nodePools {
+		for _, nodePoolNode := range nodePool.Nodes {
+			nodeName, ok := nodesByLocalVMID[nodePoolNode.LocalVMID]
+			if !ok {
+				nodeName, ok = nodesByName[nodePoolNode.Name]
+			}
+			if !ok {
+				klog.V(5).InfoS("Skip node pool node without Kubernetes node", "node_pool_id", nodePool.ID, "local_vm_id", nodePoolNode.LocalVMID)
+				continue
+			}
+			if err := s.syncNode(ctx, nodeName, nodePool); err != nil {
+				klog.ErrorS(err, "Failed to sync node pool labels and taints", "node", nodeName, "node_pool_id", nodePool.ID)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *nodePoolSyncer) syncNode(ctx context.Context, nodeName string, nodePool xelonNodePool) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		node, err := s.clients.k8s.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		updated := applyNodePool(node, nodePool)
+		if updated == nil {
+			return nil
+		}
+
+		klog.InfoS("Applying node pool labels and taints", "node", nodeName, "node_pool_id", nodePool.ID)
+		_, err = s.clients.k8s.CoreV1().Nodes().Update(ctx, updated, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// applyNodePool returns a copy of the node with labels and taints of the node
+// pool applied, or nil if the node is already up-to-date. Labels and taints
+// applied previously but removed from the node pool are removed from the node.
+func applyNodePool(node *v1.Node, nodePool xelonNodePool) *v1.Node {
+	updated := node.DeepCopy()
+	if updated.Labels == nil {
+		updated.Labels = make(map[string]string)
+	}
+	if updated.Annotations == nil {
+		updated.Annotations = make(map[string]string)
+	}
+
+	desiredLabels := make(map[string]string, len(nodePool.Labels))
+	for key, value := range nodePool.Labels {
+		if err := validateNodePoolLabel(key, value); err != nil {
+			klog.InfoS("Skip invalid node pool label", "node", node.Name, "node_pool_id", nodePool.ID, "label", key, "reason", err.Error())
+			continue
+		}
+		desiredLabels[key] = value
+	}
+	for _, key := range splitAnnotationValue(updated.Annotations[nodeAnnotationNodePoolLabels]) {
+		if _, ok := desiredLabels[key]; !ok && !isReservedNodePoolKey(key) {
+			delete(updated.Labels, key)
+		}
+	}
+	maps.Copy(updated.Labels, desiredLabels)
+
+	desiredTaints := make([]v1.Taint, 0, len(nodePool.Taints))
+	for _, taint := range nodePool.Taints {
+		desiredTaint := v1.Taint{Key: taint.Key, Value: taint.Value, Effect: v1.TaintEffect(taint.Effect)}
+		if err := validateNodePoolTaint(desiredTaint); err != nil {
+			klog.InfoS("Skip invalid node pool taint", "node", node.Name, "node_pool_id", nodePool.ID, "taint", taintID(desiredTaint), "reason", err.Error())
+			continue
+		}
+		desiredTaints = append(desiredTaints, desiredTaint)
+	}
+	previousTaints := splitAnnotationValue(updated.Annotations[nodeAnnotationNodePoolTaints])
+	updated.Spec.Taints = slices.DeleteFunc(updated.Spec.Taints, func(taint v1.Taint) bool {
+		return (slices.Contains(previousTaints, taintID(taint)) && !isReservedNodePoolKey(taint.Key)) ||
+			slices.ContainsFunc(desiredTaints, func(desired v1.Taint) bool { return desired.MatchTaint(&taint) })
+	})
+	updated.Spec.Taints = append(updated.Spec.Taints, desiredTaints...)
+
+	labelKeys := slices.Sorted(maps.Keys(desiredLabels))
+	taintIDs := make([]string, 0, len(desiredTaints))
+	for _, taint := range desiredTaints {
+		taintIDs = append(taintIDs, taintID(taint))
+	}
+	setOrDeleteAnnotation(updated.Annotations, nodeAnnotationNodePoolLabels, strings.Join(labelKeys, ","))
+	setOrDeleteAnnotation(updated.Annotations, nodeAnnotationNodePoolTaints, strings.Join(taintIDs, ","))
+
+	if maps.Equal(node.Labels, updated.Labels) &&
+		maps.Equal(node.Annotations, updated.Annotations) &&
+		taintsEqual(node.Spec.Taints, updated.Spec.Taints) {
+		return nil
+	}
+
+	return updated
+}
+
+// validateNodePoolLabel returns an error if the label is not a valid
+// Kubernetes label or uses a reserved prefix.
+func validateNodePoolLabel(key, value string) error {
+	if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+		return fmt.Errorf("invalid key: %s", strings.Join(errs, "; "))
+	}
+	if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+		return fmt.Errorf("invalid value: %s", strings.Join(errs, "; "))
+	}
+	if isReservedNodePoolKey(key) {
+		return fmt.Errorf("prefix of %s is reserved", key)
+	}
+	return nil
+}
+
+// validateNodePoolTaint returns an error if the taint is not a valid
+// Kubernetes taint or uses a reserved prefix.
+func validateNodePoolTaint(taint v1.Taint) error {
+	if err := validateNodePoolLabel(taint.Key, taint.Value); err != nil {
+		return err
+	}
+	switch taint.Effect {
+	case v1.TaintEffectNoSchedule, v1.TaintEffectPreferNoSchedule, v1.TaintEffectNoExecute:
+		return nil
+	default:
+		return fmt.Errorf("invalid effect: %q", taint.Effect)
+	}
+}
+
+// isReservedNodePoolKey returns true for label and taint keys, which are
+// managed by Kubernetes components (kubernetes.io and k8s.io prefixes) or by
+// the cloud controller manager itself, so node pools cannot overwrite them.
+// Node roles are not managed by Kubernetes and are commonly used to target
+// node pools, so node-role.kubernetes.io keys are allowed.
+func isReservedNodePoolKey(key string) bool {
+	prefix, _, ok := strings.Cut(key, "/")
+	if !ok || prefix == "node-role.kubernetes.io" {
+		return false
+	}
+	for _, reserved := range []string{"kubernetes.io", "k8s.io", "kubernetes.xelon.ch"} {
+		if prefix == reserved || strings.HasSuffix(prefix, "."+reserved) {
+			return true
+		}
+	}
+	return false
+}
+
+func taintID(taint v1.Taint) string {
+	return fmt.Sprintf("%s:%s", taint.Key, taint.Effect)
+}
+
+func taintsEqual(first, second []v1.Taint) bool {
+	return slices.EqualFunc(first, second, func(a, b v1.Taint) bool {
+		return a.Key == b.Key && a.Value == b.Value && a.Effect == b.Effect
+	})
+}
+
+func setOrDeleteAnnotation(annotations map[string]string, key, value string) {
+	if value == "" {
+		delete(annotations, key)
+		return
+	}
+	annotations[key] = value
+}
@@ -0,0 +1,155 @@
+package xelon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestApplyNodePool(t *testing.T) {
+	type testCase struct {
+		node     *v1.Node
+		nodePool xelonNodePool
+		expected *v1.Node
+	}
+	tests := map[string]testCase{
+		"nothing to apply": {
+			node:     &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-1"}},
+			nodePool: xelonNodePool{ID: "pool-id"},
+			expected: nil,
+		},
+		"apply labels and taints": {
+			node: &v1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: "worker-1", Labels: map[string]string{"kubernetes.io/hostname": "worker-1"}},
+				Spec:       v1.NodeSpec{Taints: []v1.Taint{{Key: "node.kubernetes.io/not-ready", Effect: v1.TaintEffectNoSchedule}}},
+			},
+			nodePool: xelonNodePool{
+				ID:     "pool-id",
+				Labels: map[string]string{"workload": "batch", "tier": "backend"},
+				Taints: []xelonNodePoolTaint{{Key: "dedicated", Value: "batch", Effect: "NoSchedule"}},
+			},
+			expected: &v1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "worker-1",
+					Labels: map[string]string{"kubernetes.io/hostname": "worker-1", "workload": "batch", "tier": "backend"},
+					Annotations: map[string]string{
+						nodeAnnotationNodePoolLabels: "tier,workload",
+						nodeAnnotationNodePoolTaints: "dedicated:NoSchedule",
+					},
+				},
+				Spec: v1.NodeSpec{Taints: []v1.Taint{
+					{Key: "node.kubernetes.io/not-ready", Effect: v1.TaintEffectNoSchedule},
+					{Key: "dedicated", Value: "batch", Effect: v1.TaintEffectNoSchedule},
+				}},
+			},
+		},
+		"already applied": {
+			node: &v1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "worker-1",
+					Labels: map[string]string{"workload": "batch"},
+					Annotations: map[string]string{
+						nodeAnnotationNodePoolLabels: "workload",
+						nodeAnnotationNodePoolTaints: "dedicated:NoSchedule",
+					},
+				},
+				Spec: v1.NodeSpec{Taints: []v1.Taint{{Key: "dedicated", Value: "batch", Effect: v1.TaintEffectNoSchedule}}},
+			},
+			nodePool: xelonNodePool{
+				Labels: map[string]string{"workload": "batch"},
+				Taints: []xelonNodePoolTaint{{Key: "dedicated", Value: "batch", Effect: "NoSchedule"}},
+			},
+			expected: nil,
+		},
+		"remove labels and taints deleted from node pool": {
+			node: &v1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "worker-1",
+					Labels: map[string]string{"workload": "batch", "tier": "backend", "custom": "value"},
+					Annotations: map[string]string{
+						nodeAnnotationNodePoolLabels: "tier,workload",
+						nodeAnnotationNodePoolTaints: "dedicated:NoSchedule",
+					},
+				},
+				Spec: v1.NodeSpec{Taints: []v1.Taint{
+					{Key: "dedicated", Value: "batch", Effect: v1.TaintEffectNoSchedule},
+					{Key: "custom", Effect: v1.TaintEffectNoExecute},
+				}},
+			},
+			nodePool: xelonNodePool{
+				Labels: map[string]string{"workload": "web"},
+			},
+			expected: &v1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "worker-1",
+					Labels:      map[string]string{"workload": "web", "custom": "value"},
+					Annotations: map[string]string{nodeAnnotationNodePoolLabels: "workload"},
+				},
+				Spec: v1.NodeSpec{Taints: []v1.Taint{
+					{Key: "custom", Effect: v1.TaintEffectNoExecute},
+				}},
+			},
+		},
+		"skip invalid and reserved labels and taints": {
+			node: &v1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: "worker-1", Labels: map[string]string{"kubernetes.io/hostname": "worker-1"}},
+			},
+			nodePool: xelonNodePool{
+				Labels: map[string]string{
+					"workload":               "batch",
+					"kubernetes.io/hostname": "other",
+					nodeLabelNodePoolID:      "other",
+					"invalid key":            "value",
+					"tier":                   "invalid value",
+				},
+				Taints: []xelonNodePoolTaint{
+					{Key: "dedicated", Value: "batch", Effect: "NoSchedule"},
+					{Key: "dedicated", Value: "batch", Effect: "Invalid"},
+					{Key: "node.kubernetes.io/unschedulable", Effect: "NoSchedule"},
+				},
+			},
+			expected: &v1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "worker-1",
+					Labels: map[string]string{"kubernetes.io/hostname": "worker-1", "workload": "batch"},
+					Annotations: map[string]string{
+						nodeAnnotationNodePoolLabels: "workload",
+						nodeAnnotationNodePoolTaints: "dedicated:NoSchedule",
+					},
+				},
+				Spec: v1.NodeSpec{Taints: []v1.Taint{
+					{Key: "dedicated", Value: "batch", Effect: v1.TaintEffectNoSchedule},
+				}},
+			},
+		},
+		"node role labels and taints": {
+			node: &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-1"}},
+			nodePool: xelonNodePool{
+				Labels: map[string]string{"node-role.kubernetes.io/worker": ""},
+				Taints: []xelonNodePoolTaint{{Key: "node-role.kubernetes.io/worker", Effect: "NoSchedule"}},
+			},
+			expected: &v1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "worker-1",
+					Labels: map[string]string{"node-role.kubernetes.io/worker": ""},
+					Annotations: map[string]string{
+						nodeAnnotationNodePoolLabels: "node-role.kubernetes.io/worker",
+						nodeAnnotationNodePoolTaints: "node-role.kubernetes.io/worker:NoSchedule",
+					},
+				},
+				Spec: v1.NodeSpec{Taints: []v1.Taint{
+					{Key: "node-role.kubernetes.io/worker", Effect: v1.TaintEffectNoSchedule},
+				}},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			actual := applyNodePool(test.node, test.nodePool)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
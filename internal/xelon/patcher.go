@@ -16,13 +16,17 @@ type servicePatcher struct {
 	k8sClient kubernetes.Interface
 	current   *v1.Service
 	modified  *v1.Service
+
+	// dryRun sends the patch as server-side dry-run, so it is validated but not persisted
+	dryRun bool
 }
 
-func newServicePatcher(k8sClient kubernetes.Interface, service *v1.Service) servicePatcher {
+func newServicePatcher(k8sClient kubernetes.Interface, service *v1.Service, dryRun bool) servicePatcher {
 	return servicePatcher{
 		k8sClient: k8sClient,
 		current:   service.DeepCopy(),
 		modified:  service,
+		dryRun:    dryRun,
 	}
 }
 
@@ -44,7 +48,11 @@ func (p *servicePatcher) Patch(ctx context.Context) error {
 	if len(patch) == 0 || string(patch) == "{}" {
 		return nil
 	}
-	_, err = p.k8sClient.CoreV1().Services(p.current.Namespace).Patch(ctx, p.current.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+	patchOptions := metav1.PatchOptions{}
+	if p.dryRun {
+		patchOptions.DryRun = []string{metav1.DryRunAll}
+	}
+	_, err = p.k8sClient.CoreV1().Services(p.current.Namespace).Patch(ctx, p.current.Name, types.StrategicMergePatchType, patch, patchOptions)
 	if err != nil {
 		return fmt.Errorf("failed to patch service object %s/%s: %s", p.current.Namespace, p.current.Name, err)
 	}
@@ -0,0 +1,134 @@
+package xelon
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	cloudprovider "k8s.io/cloud-provider"
+	"k8s.io/klog/v2"
+)
+
+// routeDescriptionPrefix marks routes managed by the cloud controller manager,
+// the description has the following form kubernetes/<cluster_name>/<node_name>.
+const routeDescriptionPrefix = "kubernetes/"
+
+var _ cloudprovider.Routes = (*routes)(nil)
+
+// routes programs pod CIDR routes of the nodes on Xelon private network, so
+// pods can be reached without overlay networking.
+type routes struct {
+	client    *clients
+	tenant    *tenantResolver
+	networkID string
+}
+
+func newRoutes(clients *clients, tenant *tenantResolver, networkID string) *routes {
+	return &routes{
+		client:    clients,
+		tenant:    tenant,
+		networkID: networkID,
+	}
+}
+
+// ListRoutes lists routes of the network managed for clusterName.
+func (r *routes) ListRoutes(ctx context.Context, clusterName string) ([]*cloudprovider.Route, error) {
+	networkRoutes, err := r.listNetworkRoutes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var clusterRoutes []*cloudprovider.Route
+	for _, networkRoute := range networkRoutes {
+		nodeName, ok := parseRouteDescription(networkRoute.Description, clusterName)
+		if !ok {
+			continue
+		}
+		clusterRoutes = append(clusterRoutes, &cloudprovider.Route{
+			Name:            networkRoute.ID,
+			TargetNode:      types.NodeName(nodeName),
+			DestinationCIDR: networkRoute.Destination,
+		})
+	}
+
+	return clusterRoutes, nil
+}
+
+// CreateRoute creates a route to the pod CIDR of the node via its internal IP
+// in the subnet of the network.
+func (r *routes) CreateRoute(ctx context.Context, clusterName string, nameHint string, route *cloudprovider.Route) error {
+	tenantID, err := r.tenant.tenantID(ctx)
+	if err != nil {
+		return err
+	}
+	network, err := getXelonNetwork(ctx, r.client.xelon(), tenantID, r.networkID)
+	if err != nil {
+		return err
+	}
+	_, subnet, err := net.ParseCIDR(network.Subnet)
+	if err != nil {
+		return fmt.Errorf("invalid subnet of network %s: %w", r.networkID, err)
+	}
+
+	gateway := ""
+	for _, address := range route.TargetNodeAddresses {
+		ip := net.ParseIP(address.Address)
+		if address.Type == v1.NodeInternalIP && ip != nil && subnet.Contains(ip) {
+			gateway = address.Address
+			break
+		}
+	}
+	if gateway == "" {
+		return fmt.Errorf("node %s has no internal IP address in subnet %s of network %s to route %s to", route.TargetNode, subnet, r.networkID, route.DestinationCIDR)
+	}
+
+	networkRoute := &xelonNetworkRoute{
+		Destination: route.DestinationCIDR,
+		Gateway:     gateway,
+		Description: formatRouteDescription(clusterName, string(route.TargetNode)),
+	}
+	klog.InfoS("Creating route on Xelon network", "network_id", r.networkID, "node", route.TargetNode, "destination", networkRoute.Destination, "gateway", gateway, "name_hint", nameHint)
+	_, err = createXelonNetworkRoute(ctx, r.client.xelon(), tenantID, r.networkID, networkRoute)
+	return err
+}
+
+// DeleteRoute deletes a route returned by ListRoutes.
+func (r *routes) DeleteRoute(ctx context.Context, clusterName string, route *cloudprovider.Route) error {
+	tenantID, err := r.tenant.tenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	klog.InfoS("Deleting route on Xelon network", "network_id", r.networkID, "node", route.TargetNode, "destination", route.DestinationCIDR, "route_id", route.Name)
+	resp, err := deleteXelonNetworkRoute(ctx, r.client.xelon(), tenantID, r.networkID, route.Name)
+	if err != nil && resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+func (r *routes) listNetworkRoutes(ctx context.Context) ([]xelonNetworkRoute, error) {
+	tenantID, err := r.tenant.tenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return listXelonNetworkRoutes(ctx, r.client.xelon(), tenantID, r.networkID)
+}
+
+func formatRouteDescription(clusterName, nodeName string) string {
+	return fmt.Sprintf("%s%s/%s", routeDescriptionPrefix, clusterName, nodeName)
+}
+
+// parseRouteDescription returns node name if route is managed for clusterName.
+func parseRouteDescription(description, clusterName string) (string, bool) {
+	nodeName, ok := strings.CutPrefix(description, routeDescriptionPrefix+clusterName+"/")
+	if !ok || nodeName == "" || strings.Contains(nodeName, "/") {
+		return "", false
+	}
+	return nodeName, true
+}
@@ -0,0 +1,153 @@
+package xelon
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	cloudprovider "k8s.io/cloud-provider"
+
+	"github.com/Xelon-AG/xelon-sdk-go/xelon"
+)
+
+func TestRoutes_ListRoutes(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /tenant-id/networks/network-id/routes", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"data":[
+			{"id":"route-1","destination":"10.244.0.0/24","gateway":"10.0.0.10","description":"kubernetes/cluster/worker-1"},
+			{"id":"route-2","destination":"10.245.0.0/24","gateway":"10.0.0.20","description":"kubernetes/other-cluster/worker-1"},
+			{"id":"route-3","destination":"192.168.0.0/16","gateway":"10.0.0.1","description":"manual route"}
+		]}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	r := newRoutes(newClients(xelon.NewClient("token", xelon.WithBaseURL(server.URL+"/"))), &tenantResolver{id: "tenant-id", resolved: true}, "network-id")
+
+	actual, err := r.ListRoutes(context.Background(), "cluster")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []*cloudprovider.Route{
+		{Name: "route-1", TargetNode: "worker-1", DestinationCIDR: "10.244.0.0/24"},
+	}, actual)
+}
+
+func TestRoutes_CreateRoute(t *testing.T) {
+	type testCase struct {
+		route         *cloudprovider.Route
+		expectedRoute *xelonNetworkRoute
+		expectedErr   bool
+	}
+	tests := map[string]testCase{
+		"node with internal ip": {
+			route: &cloudprovider.Route{
+				TargetNode:      "worker-1",
+				DestinationCIDR: "10.244.0.0/24",
+				TargetNodeAddresses: []v1.NodeAddress{
+					{Type: v1.NodeExternalIP, Address: "185.1.2.3"},
+					{Type: v1.NodeInternalIP, Address: "10.0.0.10"},
+				},
+			},
+			expectedRoute: &xelonNetworkRoute{Destination: "10.244.0.0/24", Gateway: "10.0.0.10", Description: "kubernetes/cluster/worker-1"},
+		},
+		"node with internal ips in several networks": {
+			route: &cloudprovider.Route{
+				TargetNode:      "worker-1",
+				DestinationCIDR: "10.244.0.0/24",
+				TargetNodeAddresses: []v1.NodeAddress{
+					{Type: v1.NodeInternalIP, Address: "192.168.0.10"},
+					{Type: v1.NodeInternalIP, Address: "10.0.0.10"},
+				},
+			},
+			expectedRoute: &xelonNetworkRoute{Destination: "10.244.0.0/24", Gateway: "10.0.0.10", Description: "kubernetes/cluster/worker-1"},
+		},
+		"node without internal ip in network": {
+			route: &cloudprovider.Route{
+				TargetNode:          "worker-1",
+				DestinationCIDR:     "10.244.0.0/24",
+				TargetNodeAddresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "192.168.0.10"}},
+			},
+			expectedErr: true,
+		},
+		"node without internal ip": {
+			route: &cloudprovider.Route{
+				TargetNode:          "worker-1",
+				DestinationCIDR:     "10.244.0.0/24",
+				TargetNodeAddresses: []v1.NodeAddress{{Type: v1.NodeHostName, Address: "worker-1"}},
+			},
+			expectedErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var created *xelonNetworkRoute
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /tenant-id/networks/network-id", func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte(`{"data":{"id":"network-id","subnet":"10.0.0.0/24"}}`))
+			})
+			mux.HandleFunc("POST /tenant-id/networks/network-id/routes", func(w http.ResponseWriter, r *http.Request) {
+				created = new(xelonNetworkRoute)
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(created))
+				_, _ = w.Write([]byte(`{"data":{"id":"route-1"}}`))
+			})
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			r := newRoutes(newClients(xelon.NewClient("token", xelon.WithBaseURL(server.URL+"/"))), &tenantResolver{id: "tenant-id", resolved: true}, "network-id")
+
+			err := r.CreateRoute(context.Background(), "cluster", "node-uid", test.route)
+
+			if test.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.expectedRoute, created)
+		})
+	}
+}
+
+func TestRoutes_DeleteRoute(t *testing.T) {
+	type testCase struct {
+		statusCode  int
+		expectedErr bool
+	}
+	tests := map[string]testCase{
+		"deleted": {
+			statusCode: http.StatusNoContent,
+		},
+		"already deleted": {
+			statusCode: http.StatusNotFound,
+		},
+		"api error": {
+			statusCode:  http.StatusInternalServerError,
+			expectedErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("DELETE /tenant-id/networks/network-id/routes/route-1", func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(test.statusCode)
+			})
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			r := newRoutes(newClients(xelon.NewClient("token", xelon.WithBaseURL(server.URL+"/"))), &tenantResolver{id: "tenant-id", resolved: true}, "network-id")
+
+			err := r.DeleteRoute(context.Background(), "cluster", &cloudprovider.Route{Name: "route-1", TargetNode: "worker-1"})
+
+			if test.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package xelon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+)
+
+var errTenantNotResolved = errors.New("xelon tenant is not resolved yet")
+
+// tenantResolver lazily resolves Xelon tenant for the current credentials,
+// so temporary Xelon API outages do not prevent the CCM from starting.
+type tenantResolver struct {
+	clients *clients
+
+	mu       sync.RWMutex
+	id       string
+	resolved bool
+	lastErr  error
+}
+
+func newTenantResolver(clients *clients) *tenantResolver {
+	return &tenantResolver{clients: clients}
+}
+
+// tenantID returns cached tenant id or fetches it from Xelon API.
+func (r *tenantResolver) tenantID(ctx context.Context) (string, error) {
+	r.mu.RLock()
+	id, resolved := r.id, r.resolved
+	r.mu.RUnlock()
+	if resolved {
+		return id, nil
+	}
+
+	tenant, _, err := r.clients.xelon().Tenants.GetCurrent(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		r.lastErr = err
+		return "", fmt.Errorf("%w: %w", errTenantNotResolved, err)
+	}
+	r.id = tenant.ID
+	r.resolved = true
+	r.lastErr = nil
+
+	return r.id, nil
+}
+
+// reset replaces the resolved tenant, e.g. once credentials of another tenant
+// are loaded.
+func (r *tenantResolver) reset(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.resolved && r.id != id {
+		klog.InfoS("Xelon tenant has changed", "tenant_id", id, "previous_tenant_id", r.id)
+	}
+	r.id = id
+	r.resolved = true
+	r.lastErr = nil
+}
+
+// run resolves tenant in the background and retries with exponential backoff
+// until it succeeds or ctx is cancelled.
+func (r *tenantResolver) run(ctx context.Context) {
+	backoff := wait.Backoff{
+		Duration: time.Second,
+		Factor:   2,
+		Jitter:   0.1,
+		Steps:    math.MaxInt32,
+		Cap:      5 * time.Minute,
+	}
+	_ = wait.ExponentialBackoffWithContext(ctx, backoff, func(ctx context.Context) (bool, error) {
+		id, err := r.tenantID(ctx)
+		if err != nil {
+			klog.ErrorS(err, "Xelon API is not reachable, retrying")
+			return false, nil
+		}
+		klog.InfoS("Resolved Xelon tenant", "tenant_id", id)
+		return true, nil
+	})
+}
+
+// Check implements healthz check and reports not ready until tenant is resolved.
+func (r *tenantResolver) Check(_ *http.Request) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.resolved {
+		return nil
+	}
+	if r.lastErr != nil {
+		return fmt.Errorf("%w: %w", errTenantNotResolved, r.lastErr)
+	}
+	return errTenantNotResolved
+}
@@ -0,0 +1,44 @@
+package xelon
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Xelon-AG/xelon-sdk-go/xelon"
+)
+
+func TestTenantResolver_tenantID(t *testing.T) {
+	var reachable atomic.Bool
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		if !reachable.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	r := newTenantResolver(newClients(xelon.NewClient("token", xelon.WithBaseURL(server.URL+"/"))))
+
+	assert.ErrorIs(t, r.Check(nil), errTenantNotResolved)
+
+	_, err := r.tenantID(context.Background())
+	assert.ErrorIs(t, err, errTenantNotResolved)
+	assert.ErrorIs(t, r.Check(nil), errTenantNotResolved)
+
+	reachable.Store(true)
+	_, err = r.tenantID(context.Background())
+	assert.NoError(t, err)
+	assert.NoError(t, r.Check(nil))
+
+	// resolved tenant is cached
+	_, _ = r.tenantID(context.Background())
+	assert.Equal(t, int32(2), requests.Load())
+}
@@ -0,0 +1,208 @@
+package xelon
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/Xelon-AG/xelon-sdk-go/xelon"
+)
+
+// This file contains typed access to Xelon API endpoints, which are not yet
+// covered by xelon-sdk-go services. Requests are sent with the SDK client,
+// so authentication, user agent and error handling are the same as for SDK
+// services. Endpoints are kept in one place, so they can be replaced by SDK
+// services once these are available.
+
+// xelonDevice represents Xelon device (virtual machine) with fields required
+// by the cloud controller manager.
+type xelonDevice struct {
+	LocalVMID         string                        `json:"localvmid"`
+	Name              string                        `json:"name"`
+	HostName          string                        `json:"hostname"`
+	PowerState        *bool                         `json:"powerstate"`
+	State             string                        `json:"state"`
+	Networks          []xelonDeviceNetwork          `json:"networks"`
+	Cloud             *xelonDeviceCloud             `json:"cloud"`
+	HypervisorCluster *xelonDeviceHypervisorCluster `json:"hvCluster"`
+	Product           *xelonDeviceProduct           `json:"product"`
+	CPUCores          int                           `json:"cpuCores"`
+	RAM               int                           `json:"ram"`
+	DiskSize          int                           `json:"diskSize"`
+}
+
+// xelonDeviceProduct represents Xelon product (plan) the device is ordered with.
+type xelonDeviceProduct struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// xelonDeviceCloud represents Xelon cloud (data centre) the device runs in.
+type xelonDeviceCloud struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// xelonDeviceHypervisorCluster represents hypervisor cluster the device runs on.
+type xelonDeviceHypervisorCluster struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// xelonDeviceNetwork represents a network interface of Xelon device.
+type xelonDeviceNetwork struct {
+	Name      string `json:"name"`
+	IPAddress string `json:"ipAddress"`
+}
+
+type xelonDeviceRoot struct {
+	Device *xelonDevice `json:"device"`
+}
+
+type xelonDevicesRoot struct {
+	Devices []xelonDevice `json:"data"`
+}
+
+// getXelonDevice fetches a single device by its local VM id.
+func getXelonDevice(ctx context.Context, client *xelon.Client, tenantID, localVMID string) (*xelonDevice, *xelon.Response, error) {
+	root := new(xelonDeviceRoot)
+	resp, err := doXelonRequest(ctx, client, http.MethodGet, fmt.Sprintf("%s/devices/%s", tenantID, localVMID), nil, root)
+	if err != nil {
+		return nil, resp, err
+	}
+	if root.Device == nil {
+		return nil, resp, fmt.Errorf("device %s is missing in Xelon API response", localVMID)
+	}
+
+	return root.Device, resp, nil
+}
+
+// findXelonDevice searches devices by name and returns the one with exactly
+// matching name or hostname, or nil if there is no such device.
+func findXelonDevice(ctx context.Context, client *xelon.Client, tenantID, name string) (*xelonDevice, error) {
+	root := new(xelonDevicesRoot)
+	path := fmt.Sprintf("%s/devices?search=%s", tenantID, url.QueryEscape(name))
+	if _, err := doXelonRequest(ctx, client, http.MethodGet, path, nil, root); err != nil {
+		return nil, err
+	}
+	for _, device := range root.Devices {
+		if device.Name == name || device.HostName == name {
+			return &device, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// xelonNodePool represents Xelon node pool with labels and taints, which
+// should be applied to Kubernetes nodes of the pool.
+type xelonNodePool struct {
+	ID     string               `json:"id"`
+	Name   string               `json:"name"`
+	Labels map[string]string    `json:"labels"`
+	Taints []xelonNodePoolTaint `json:"taints"`
+	Nodes  []xelonNodePoolNode  `json:"nodes"`
+}
+
+type xelonNodePoolTaint struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Effect string `json:"effect"`
+}
+
+type xelonNodePoolNode struct {
+	LocalVMID string `json:"localvmid"`
+	Name      string `json:"name"`
+}
+
+// listXelonNodePools fetches node pools of Kubernetes cluster including labels and taints.
+func listXelonNodePools(ctx context.Context, client *xelon.Client, clusterID string) ([]xelonNodePool, error) {
+	var nodePools []xelonNodePool
+	if _, err := doXelonRequest(ctx, client, http.MethodGet, fmt.Sprintf("kubernetes/%s/pools", clusterID), nil, &nodePools); err != nil {
+		return nil, err
+	}
+
+	return nodePools, nil
+}
+
+// xelonNetworkRoute represents a static route on Xelon private network.
+type xelonNetworkRoute struct {
+	ID          string `json:"id,omitempty"`
+	Destination string `json:"destination"`
+	Gateway     string `json:"gateway"`
+	Description string `json:"description"`
+}
+
+type xelonNetworkRoutesRoot struct {
+	Routes []xelonNetworkRoute `json:"data"`
+}
+
+type xelonNetworkRouteRoot struct {
+	Route *xelonNetworkRoute `json:"data"`
+}
+
+// listXelonNetworkRoutes fetches static routes of a private network.
+func listXelonNetworkRoutes(ctx context.Context, client *xelon.Client, tenantID, networkID string) ([]xelonNetworkRoute, error) {
+	root := new(xelonNetworkRoutesRoot)
+	if _, err := doXelonRequest(ctx, client, http.MethodGet, fmt.Sprintf("%s/networks/%s/routes", tenantID, networkID), nil, root); err != nil {
+		return nil, err
+	}
+
+	return root.Routes, nil
+}
+
+// createXelonNetworkRoute creates a static route on a private network.
+func createXelonNetworkRoute(ctx context.Context, client *xelon.Client, tenantID, networkID string, route *xelonNetworkRoute) (*xelonNetworkRoute, error) {
+	root := new(xelonNetworkRouteRoot)
+	if _, err := doXelonRequest(ctx, client, http.MethodPost, fmt.Sprintf("%s/networks/%s/routes", tenantID, networkID), route, root); err != nil {
+		return nil, err
+	}
+
+	return root.Route, nil
+}
+
+// deleteXelonNetworkRoute deletes a static route of a private network.
+func deleteXelonNetworkRoute(ctx context.Context, client *xelon.Client, tenantID, networkID, routeID string) (*xelon.Response, error) {
+	return doXelonRequest(ctx, client, http.MethodDelete, fmt.Sprintf("%s/networks/%s/routes/%s", tenantID, networkID, routeID), nil, nil)
+}
+
+// xelonNetwork represents Xelon private network with ranges reserved for pods
+// of Kubernetes clusters attached to the network.
+type xelonNetwork struct {
+	ID             string                      `json:"id"`
+	Name           string                      `json:"name"`
+	Subnet         string                      `json:"subnet"`
+	ReservedRanges []xelonNetworkReservedRange `json:"reservedRanges"`
+}
+
+type xelonNetworkReservedRange struct {
+	CIDR                string `json:"cidr"`
+	KubernetesClusterID string `json:"kubernetesClusterId"`
+}
+
+type xelonNetworkRoot struct {
+	Network *xelonNetwork `json:"data"`
+}
+
+// getXelonNetwork fetches a single private network by its id.
+func getXelonNetwork(ctx context.Context, client *xelon.Client, tenantID, networkID string) (*xelonNetwork, error) {
+	root := new(xelonNetworkRoot)
+	if _, err := doXelonRequest(ctx, client, http.MethodGet, fmt.Sprintf("%s/networks/%s", tenantID, networkID), nil, root); err != nil {
+		return nil, err
+	}
+	if root.Network == nil {
+		return nil, fmt.Errorf("network %s is missing in Xelon API response", networkID)
+	}
+
+	return root.Network, nil
+}
+
+// doXelonRequest sends a request to Xelon API and decodes the response into v.
+func doXelonRequest(ctx context.Context, client *xelon.Client, method, path string, body, v any) (*xelon.Response, error) {
+	req, err := client.NewRequest(method, path, body)
+	if err != nil {
+		return nil, err
+	}
+	return client.Do(ctx, req, v)
+}